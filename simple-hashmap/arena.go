@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"hash/maphash"
+	"iter"
+	"math"
+)
+
+// ArenaMap is a string-to-string map for very large key counts. Keys and
+// values are copied into one byte arena and the slots only hold offsets
+// and lengths, so the whole map is two pointer-free allocations the GC
+// never has to scan, instead of an Entry and two strings per key.
+//
+// Slots use linear probing with backward-shift deletion. Replaced and
+// deleted bytes stay in the arena as garbage until the next rehash, which
+// copies only live entries into a fresh arena. Get copies the value out,
+// since the arena is reused.
+type ArenaMap struct {
+	slots    []arenaSlot
+	arena    []byte
+	mask     uint64
+	occupied uint64
+	maxLoad  uint64
+	garbage  uint64 // arena bytes no slot refers to
+	seed     maphash.Seed
+}
+
+// arenaSlot is empty when off is 0; the arena starts with a padding byte
+// so that no entry sits at offset 0.
+type arenaSlot struct {
+	hash uint64
+	off  uint64
+	klen uint32
+	vlen uint32
+}
+
+const (
+	arenaMaxLoadNum = 3
+	arenaMaxLoadDen = 4
+
+	// Below this size garbage is left for the next growth to collect.
+	arenaMinCompact = 4096
+)
+
+func NewArenaMap(initialSize uint64) (*ArenaMap, error) {
+	if initialSize < 1 {
+		return nil, errors.New("Invalid size.")
+	}
+
+	a := &ArenaMap{seed: maphash.MakeSeed()}
+	a.init(nextPowerOfTwo(initialSize), make([]byte, 1, 64))
+	return a, nil
+}
+
+func (a *ArenaMap) init(size uint64, arena []byte) {
+	a.slots = make([]arenaSlot, size)
+	a.arena = arena
+	a.mask = size - 1
+	a.occupied = 0
+	a.garbage = 0
+
+	// Rounding down keeps at least one slot empty, which ends every probe.
+	a.maxLoad = size * arenaMaxLoadNum / arenaMaxLoadDen
+}
+
+func (a *ArenaMap) key(s *arenaSlot) []byte {
+	return a.arena[s.off : s.off+uint64(s.klen)]
+}
+
+func (a *ArenaMap) value(s *arenaSlot) []byte {
+	start := s.off + uint64(s.klen)
+	return a.arena[start : start+uint64(s.vlen)]
+}
+
+// find returns the slot holding key, or the empty slot ending its cluster.
+// It returns len(a.slots) if the table is full, which the load limit
+// prevents.
+func (a *ArenaMap) find(key string, hash uint64) (uint64, bool) {
+	i := hash & a.mask
+
+	for range len(a.slots) {
+		s := &a.slots[i]
+
+		if s.off == 0 {
+			return i, false
+		}
+
+		// The conversion in the comparison does not allocate.
+		if s.hash == hash && int(s.klen) == len(key) && string(a.key(s)) == key {
+			return i, true
+		}
+
+		i = (i + 1) & a.mask
+	}
+
+	return uint64(len(a.slots)), false
+}
+
+func (a *ArenaMap) Put(key, value string) error {
+	if uint64(len(key)) > math.MaxUint32 || uint64(len(value)) > math.MaxUint32 {
+		return errors.New("Entry too large.")
+	}
+
+	hash := maphash.String(a.seed, key)
+	i, found := a.find(key, hash)
+
+	if found {
+		s := &a.slots[i]
+
+		// A value that fits is overwritten in place.
+		if len(value) <= int(s.vlen) {
+			copy(a.value(s), value)
+			a.garbage += uint64(s.vlen) - uint64(len(value))
+			s.vlen = uint32(len(value))
+			return nil
+		}
+
+		a.garbage += uint64(s.klen) + uint64(s.vlen)
+		s.off = a.appendEntry(key, value)
+		s.vlen = uint32(len(value))
+		a.maybeCompact()
+		return nil
+	}
+
+	if a.occupied >= a.maxLoad {
+		a.rehash(uint64(len(a.slots)) << 1)
+		i, _ = a.find(key, hash)
+	}
+
+	if i == uint64(len(a.slots)) {
+		panic("ArenaMap is full")
+	}
+
+	a.slots[i] = arenaSlot{
+		hash: hash,
+		off:  a.appendEntry(key, value),
+		klen: uint32(len(key)),
+		vlen: uint32(len(value)),
+	}
+	a.occupied++
+	return nil
+}
+
+func (a *ArenaMap) appendEntry(key, value string) uint64 {
+	off := uint64(len(a.arena))
+	a.arena = append(a.arena, key...)
+	a.arena = append(a.arena, value...)
+	return off
+}
+
+// maybeCompact rebuilds the table at the same size once garbage makes up
+// more than half of the arena.
+func (a *ArenaMap) maybeCompact() {
+	if len(a.arena) >= arenaMinCompact && a.garbage > uint64(len(a.arena))/2 {
+		a.rehash(uint64(len(a.slots)))
+	}
+}
+
+func (a *ArenaMap) Get(key string) (string, error) {
+	value, _ := a.Lookup(key)
+	return value, nil
+}
+
+func (a *ArenaMap) Lookup(key string) (string, bool) {
+	i, found := a.find(key, maphash.String(a.seed, key))
+	if !found {
+		return "", false
+	}
+
+	return string(a.value(&a.slots[i])), true
+}
+
+func (a *ArenaMap) Delete(key string) (bool, error) {
+	i, found := a.find(key, maphash.String(a.seed, key))
+	if !found {
+		return false, nil
+	}
+
+	a.garbage += uint64(a.slots[i].klen) + uint64(a.slots[i].vlen)
+	a.slots[i] = arenaSlot{}
+	a.occupied--
+
+	// The entry may fill the hole only if its home is not in (i, j].
+	for j := (i + 1) & a.mask; a.slots[j].off != 0; j = (j + 1) & a.mask {
+		if (j-a.slots[j].hash)&a.mask >= (j-i)&a.mask {
+			a.slots[i] = a.slots[j]
+			a.slots[j] = arenaSlot{}
+			i = j
+		}
+	}
+
+	a.maybeCompact()
+	return true, nil
+}
+
+func (a *ArenaMap) Len() int {
+	return int(a.occupied)
+}
+
+// ArenaSize returns the bytes in use by the arena, garbage included.
+func (a *ArenaMap) ArenaSize() int {
+	return len(a.arena)
+}
+
+// rehash moves every live entry into a table of size slots and a compacted
+// arena. The cached hashes mean no key is hashed again.
+func (a *ArenaMap) rehash(size uint64) {
+	oldSlots, oldArena := a.slots, a.arena
+
+	a.init(size, make([]byte, 1, uint64(len(oldArena))-a.garbage))
+
+	for k := range oldSlots {
+		s := oldSlots[k]
+		if s.off == 0 {
+			continue
+		}
+
+		i := s.hash & a.mask
+		for a.slots[i].off != 0 {
+			i = (i + 1) & a.mask
+		}
+
+		entry := oldArena[s.off : s.off+uint64(s.klen)+uint64(s.vlen)]
+		s.off = uint64(len(a.arena))
+		a.arena = append(a.arena, entry...)
+		a.slots[i] = s
+		a.occupied++
+	}
+}
+
+// All iterates over copies of the keys and values. The loop body must not
+// change the map.
+func (a *ArenaMap) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for k := range a.slots {
+			s := &a.slots[k]
+			if s.off == 0 {
+				continue
+			}
+
+			if !yield(string(a.key(s)), string(a.value(s))) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Test puts, deletes and iteration, including the empty key
+func TestArenaMap_Basic(t *testing.T) {
+	a, err := NewArenaMap(4)
+	if err != nil {
+		t.Fatalf("Failed to create ArenaMap: %v", err)
+	}
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		if err := a.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)); err != nil {
+			t.Fatalf("Failed to put key%d: %v", i, err)
+		}
+	}
+
+	a.Put("", "empty key")
+
+	for i := 0; i < n; i += 2 {
+		if deleted, _ := a.Delete(fmt.Sprintf("key%d", i)); !deleted {
+			t.Fatalf("Failed to delete key%d", i)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		value, ok := a.Lookup(fmt.Sprintf("key%d", i))
+
+		if i%2 == 0 && ok {
+			t.Errorf("Expected key%d to be deleted", i)
+		}
+		if i%2 == 1 && value != fmt.Sprintf("value%d", i) {
+			t.Errorf("Expected value%d, got (%q, %v)", i, value, ok)
+		}
+	}
+
+	if value, _ := a.Get(""); value != "empty key" {
+		t.Errorf("Expected the empty key to be stored, got %q", value)
+	}
+
+	if a.Len() != n/2+1 {
+		t.Errorf("Expected %d entries, got %d", n/2+1, a.Len())
+	}
+
+	count := 0
+	for key, value := range a.All() {
+		if key != "" && strings.TrimPrefix(key, "key") != strings.TrimPrefix(value, "value") {
+			t.Errorf("Mismatched pair %q=%q", key, value)
+		}
+		count++
+	}
+
+	if count != a.Len() {
+		t.Errorf("Expected %d pairs, got %d", a.Len(), count)
+	}
+}
+
+// Test that a one-slot map grows before it fills, so a miss still ends
+func TestArenaMap_SingleSlot(t *testing.T) {
+	a, err := NewArenaMap(1)
+	if err != nil {
+		t.Fatalf("Failed to create ArenaMap: %v", err)
+	}
+
+	a.Put("a", "1")
+
+	if _, ok := a.Lookup("b"); ok {
+		t.Error("Expected missing key to be absent")
+	}
+	if value, _ := a.Get("a"); value != "1" {
+		t.Errorf("Expected 1, got %q", value)
+	}
+}
+
+// Test that overwrites reuse arena bytes and returned values are copies
+func TestArenaMap_Overwrite(t *testing.T) {
+	a, err := NewArenaMap(8)
+	if err != nil {
+		t.Fatalf("Failed to create ArenaMap: %v", err)
+	}
+
+	a.Put("key", "a long initial value")
+	size := a.ArenaSize()
+
+	// A shorter value reuses the old bytes
+	a.Put("key", "short")
+	if a.ArenaSize() != size {
+		t.Errorf("Expected in-place overwrite, arena grew from %d to %d", size, a.ArenaSize())
+	}
+
+	a.Put("key", "a value longer than the first one")
+	if value, _ := a.Get("key"); value != "a value longer than the first one" {
+		t.Errorf("Unexpected value %q", value)
+	}
+
+	// Values returned earlier are copies
+	value, _ := a.Get("key")
+	a.Put("key", "xxxxx")
+	if value != "a value longer than the first one" {
+		t.Errorf("Expected returned value to be unaffected by later writes, got %q", value)
+	}
+}
+
+// Test that compaction keeps the arena close to its live bytes
+func TestArenaMap_Compaction(t *testing.T) {
+	a, err := NewArenaMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create ArenaMap: %v", err)
+	}
+
+	for i := 0; i < 40; i++ {
+		a.Put(fmt.Sprintf("key%d", i), "")
+	}
+
+	// Growing values leave the old bytes behind until compaction
+	for round := 1; round <= 50; round++ {
+		value := strings.Repeat("v", round)
+		for i := 0; i < 40; i++ {
+			a.Put(fmt.Sprintf("key%d", i), value)
+		}
+	}
+
+	live := 40 * (len("keyNN") + 50)
+	if a.ArenaSize() > 4*live {
+		t.Errorf("Expected compaction to bound the arena near %d bytes, got %d", live, a.ArenaSize())
+	}
+
+	for i := 0; i < 40; i++ {
+		if value, _ := a.Get(fmt.Sprintf("key%d", i)); len(value) != 50 {
+			t.Errorf("Expected 50 byte value for key%d, got %d", i, len(value))
+		}
+	}
+
+	if _, err := NewArenaMap(0); err == nil {
+		t.Error("Expected error for size 0, got nil")
+	}
+}
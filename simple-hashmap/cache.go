@@ -0,0 +1,482 @@
+package main
+
+import "errors"
+
+// Cache is a HashMap bounded by an entry count, a byte budget, or both.
+// When a Put goes over a bound the eviction policy picks entries to drop
+// until the cache fits again. A Cache is not safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	m      *HashMap[K, *cacheNode[K, V]]
+	policy evictionPolicy[K, V]
+	opts   cacheOptions[K, V]
+	bytes  int64
+	stats  CacheStats
+}
+
+// EvictionPolicy selects which entry a full Cache drops.
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU EvictionPolicy = iota
+	// LFU evicts the least frequently used entry, the least recently used
+	// one among equals.
+	LFU
+	// CLOCK approximates LRU with a reference bit per entry: a hand sweeps
+	// the entries, giving referenced ones a second chance.
+	CLOCK
+)
+
+// CacheStats counts cache activity since creation.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+type cacheOptions[K comparable, V any] struct {
+	maxEntries int
+	maxBytes   int64
+	sizeOf     func(K, V) int64
+	onEvict    func(K, V)
+	mapOpts    []Option
+}
+
+// CacheOption configures a Cache.
+type CacheOption[K comparable, V any] func(*cacheOptions[K, V]) error
+
+// WithMaxEntries bounds the number of entries.
+func WithMaxEntries[K comparable, V any](n int) CacheOption[K, V] {
+	return func(o *cacheOptions[K, V]) error {
+		if n < 1 {
+			return errors.New("Invalid max entries.")
+		}
+
+		o.maxEntries = n
+		return nil
+	}
+}
+
+// WithMaxBytes bounds the total size of the entries as reported by sizeOf.
+func WithMaxBytes[K comparable, V any](budget int64, sizeOf func(K, V) int64) CacheOption[K, V] {
+	return func(o *cacheOptions[K, V]) error {
+		if budget < 1 {
+			return errors.New("Invalid byte budget.")
+		}
+
+		if sizeOf == nil {
+			return errors.New("Invalid size function.")
+		}
+
+		o.maxBytes = budget
+		o.sizeOf = sizeOf
+		return nil
+	}
+}
+
+// WithEvictionCallback calls fn with every entry the policy evicts. It is
+// not called for entries removed with Delete.
+func WithEvictionCallback[K comparable, V any](fn func(K, V)) CacheOption[K, V] {
+	return func(o *cacheOptions[K, V]) error {
+		o.onEvict = fn
+		return nil
+	}
+}
+
+// WithCacheMapOptions configures the underlying HashMap.
+func WithCacheMapOptions[K comparable, V any](opts ...Option) CacheOption[K, V] {
+	return func(o *cacheOptions[K, V]) error {
+		o.mapOpts = append(o.mapOpts, opts...)
+		return nil
+	}
+}
+
+// cacheNode is the HashMap value. Its links thread it through the policy's
+// lists, so no policy allocates per entry.
+type cacheNode[K comparable, V any] struct {
+	key        K
+	value      V
+	size       int64
+	prev, next *cacheNode[K, V]
+	bucket     *lfuBucket[K, V]
+	referenced bool
+}
+
+// NewCache creates a cache with the given policy. At least one of
+// WithMaxEntries and WithMaxBytes is required.
+func NewCache[K comparable, V any](policy EvictionPolicy, opts ...CacheOption[K, V]) (*Cache[K, V], error) {
+	var o cacheOptions[K, V]
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.maxEntries == 0 && o.maxBytes == 0 {
+		return nil, errors.New("Cache needs a max entries or byte budget.")
+	}
+
+	c := &Cache[K, V]{opts: o}
+
+	switch policy {
+	case LRU:
+		c.policy = newLRUPolicy[K, V]()
+	case LFU:
+		c.policy = &lfuPolicy[K, V]{}
+	case CLOCK:
+		c.policy = newClockPolicy[K, V]()
+	default:
+		return nil, errors.New("Invalid eviction policy.")
+	}
+
+	m, err := NewHashMapOf[K, *cacheNode[K, V]](16, o.mapOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.maxEntries > 0 {
+		m.Reserve(uint64(o.maxEntries))
+	}
+
+	c.m = m
+	return c, nil
+}
+
+// Put inserts or replaces key, first evicting entries until it fits within
+// the bounds. Replacing counts as a use of the entry.
+func (c *Cache[K, V]) Put(key K, value V) error {
+	var size int64
+	if c.opts.sizeOf != nil {
+		size = c.opts.sizeOf(key, value)
+
+		if size > c.opts.maxBytes {
+			return errors.New("Entry exceeds byte budget.")
+		}
+	}
+
+	if n, ok := c.m.Lookup(key); ok {
+		// The entry is taken off the policy while others make room for its
+		// new size, so it cannot be picked itself. Under LFU it then starts
+		// over with a count of one.
+		if c.overBudget(0, size-n.size) {
+			c.policy.remove(n)
+			for c.overBudget(0, size-n.size) {
+				c.evict()
+			}
+			c.policy.add(n)
+		} else {
+			c.policy.touch(n)
+		}
+
+		c.bytes += size - n.size
+		n.value = value
+		n.size = size
+		return nil
+	}
+
+	// Evicting before the insert keeps the new entry from being the victim,
+	// which it would always be under LFU.
+	for c.overBudget(1, size) {
+		c.evict()
+	}
+
+	n := &cacheNode[K, V]{key: key, value: value, size: size}
+	if err := c.m.Put(key, n); err != nil {
+		return err
+	}
+
+	c.bytes += size
+	c.policy.add(n)
+	return nil
+}
+
+// overBudget reports whether adding entries and bytes would exceed a bound.
+func (c *Cache[K, V]) overBudget(entries int, bytes int64) bool {
+	if c.opts.maxEntries > 0 && c.m.Len()+entries > c.opts.maxEntries {
+		return true
+	}
+
+	return c.opts.maxBytes > 0 && c.bytes+bytes > c.opts.maxBytes
+}
+
+func (c *Cache[K, V]) evict() {
+	n := c.policy.victim()
+	c.remove(n)
+	c.stats.Evictions++
+
+	if c.opts.onEvict != nil {
+		c.opts.onEvict(n.key, n.value)
+	}
+}
+
+func (c *Cache[K, V]) remove(n *cacheNode[K, V]) {
+	c.policy.remove(n)
+	c.m.Delete(n.key)
+	c.bytes -= n.size
+}
+
+// Get returns the value for key and records a hit or a miss.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	n, ok := c.m.Lookup(key)
+	if !ok {
+		c.stats.Misses++
+
+		var zero V
+		return zero, false
+	}
+
+	c.stats.Hits++
+	c.policy.touch(n)
+	return n.value, true
+}
+
+// Peek returns the value for key without counting it as a use.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	n, ok := c.m.peek(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	return n.value, true
+}
+
+func (c *Cache[K, V]) Delete(key K) bool {
+	n, ok := c.m.Lookup(key)
+	if !ok {
+		return false
+	}
+
+	c.remove(n)
+	return true
+}
+
+func (c *Cache[K, V]) Len() int {
+	return c.m.Len()
+}
+
+// Bytes returns the total size of the entries, or 0 without a byte budget.
+func (c *Cache[K, V]) Bytes() int64 {
+	return c.bytes
+}
+
+func (c *Cache[K, V]) Stats() CacheStats {
+	return c.stats
+}
+
+type evictionPolicy[K comparable, V any] interface {
+	add(n *cacheNode[K, V])
+	touch(n *cacheNode[K, V])
+	remove(n *cacheNode[K, V])
+	victim() *cacheNode[K, V]
+}
+
+// cacheList is an intrusive circular doubly linked list with a sentinel
+// root, so pushes and unlinks never check for nil.
+type cacheList[K comparable, V any] struct {
+	root cacheNode[K, V]
+}
+
+func (l *cacheList[K, V]) init() {
+	l.root.prev = &l.root
+	l.root.next = &l.root
+}
+
+func (l *cacheList[K, V]) empty() bool {
+	return l.root.next == &l.root
+}
+
+func (l *cacheList[K, V]) front() *cacheNode[K, V] {
+	return l.root.next
+}
+
+func (l *cacheList[K, V]) back() *cacheNode[K, V] {
+	return l.root.prev
+}
+
+func (l *cacheList[K, V]) insertAfter(n, at *cacheNode[K, V]) {
+	n.prev = at
+	n.next = at.next
+	at.next.prev = n
+	at.next = n
+}
+
+func (l *cacheList[K, V]) pushFront(n *cacheNode[K, V]) {
+	l.insertAfter(n, &l.root)
+}
+
+func (l *cacheList[K, V]) pushBack(n *cacheNode[K, V]) {
+	l.insertAfter(n, l.root.prev)
+}
+
+func (l *cacheList[K, V]) unlink(n *cacheNode[K, V]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev = nil
+	n.next = nil
+}
+
+// lruPolicy keeps entries in use order, most recent at the front.
+type lruPolicy[K comparable, V any] struct {
+	list cacheList[K, V]
+}
+
+func newLRUPolicy[K comparable, V any]() *lruPolicy[K, V] {
+	p := &lruPolicy[K, V]{}
+	p.list.init()
+	return p
+}
+
+func (p *lruPolicy[K, V]) add(n *cacheNode[K, V]) {
+	p.list.pushFront(n)
+}
+
+func (p *lruPolicy[K, V]) touch(n *cacheNode[K, V]) {
+	p.list.unlink(n)
+	p.list.pushFront(n)
+}
+
+func (p *lruPolicy[K, V]) remove(n *cacheNode[K, V]) {
+	p.list.unlink(n)
+}
+
+func (p *lruPolicy[K, V]) victim() *cacheNode[K, V] {
+	return p.list.back()
+}
+
+// lfuPolicy is the constant time LFU of Shah, Mitra and Matani: a list of
+// buckets in increasing frequency, each holding its entries in LRU order.
+type lfuPolicy[K comparable, V any] struct {
+	head *lfuBucket[K, V] // lowest frequency
+}
+
+type lfuBucket[K comparable, V any] struct {
+	freq       uint64
+	entries    cacheList[K, V]
+	prev, next *lfuBucket[K, V]
+}
+
+// bucketAfter returns the bucket for freq, creating it after prev, which
+// is nil for the head.
+func (p *lfuPolicy[K, V]) bucketAfter(prev *lfuBucket[K, V], freq uint64) *lfuBucket[K, V] {
+	next := p.head
+	if prev != nil {
+		next = prev.next
+	}
+
+	if next != nil && next.freq == freq {
+		return next
+	}
+
+	b := &lfuBucket[K, V]{freq: freq, prev: prev, next: next}
+	b.entries.init()
+
+	if prev != nil {
+		prev.next = b
+	} else {
+		p.head = b
+	}
+
+	if next != nil {
+		next.prev = b
+	}
+
+	return b
+}
+
+func (p *lfuPolicy[K, V]) add(n *cacheNode[K, V]) {
+	n.bucket = p.bucketAfter(nil, 1)
+	n.bucket.entries.pushFront(n)
+}
+
+func (p *lfuPolicy[K, V]) touch(n *cacheNode[K, V]) {
+	old := n.bucket
+	n.bucket = p.bucketAfter(old, old.freq+1)
+
+	old.entries.unlink(n)
+	n.bucket.entries.pushFront(n)
+	p.dropIfEmpty(old)
+}
+
+func (p *lfuPolicy[K, V]) remove(n *cacheNode[K, V]) {
+	n.bucket.entries.unlink(n)
+	p.dropIfEmpty(n.bucket)
+	n.bucket = nil
+}
+
+func (p *lfuPolicy[K, V]) dropIfEmpty(b *lfuBucket[K, V]) {
+	if !b.entries.empty() {
+		return
+	}
+
+	if b.prev != nil {
+		b.prev.next = b.next
+	} else {
+		p.head = b.next
+	}
+
+	if b.next != nil {
+		b.next.prev = b.prev
+	}
+}
+
+func (p *lfuPolicy[K, V]) victim() *cacheNode[K, V] {
+	return p.head.entries.back()
+}
+
+// clockPolicy keeps entries on a ring. New entries go just behind the
+// hand, so they are the last the hand reaches.
+type clockPolicy[K comparable, V any] struct {
+	ring cacheList[K, V]
+	hand *cacheNode[K, V]
+}
+
+func newClockPolicy[K comparable, V any]() *clockPolicy[K, V] {
+	p := &clockPolicy[K, V]{}
+	p.ring.init()
+	return p
+}
+
+func (p *clockPolicy[K, V]) add(n *cacheNode[K, V]) {
+	if p.hand == nil {
+		p.ring.pushBack(n)
+		p.hand = n
+		return
+	}
+
+	p.ring.insertAfter(n, p.hand.prev)
+}
+
+func (p *clockPolicy[K, V]) touch(n *cacheNode[K, V]) {
+	n.referenced = true
+}
+
+// advance moves the hand one entry along the ring, skipping the sentinel.
+func (p *clockPolicy[K, V]) advance() {
+	p.hand = p.hand.next
+	if p.hand == &p.ring.root {
+		p.hand = p.ring.front()
+	}
+}
+
+func (p *clockPolicy[K, V]) remove(n *cacheNode[K, V]) {
+	if p.hand == n {
+		p.advance()
+	}
+
+	p.ring.unlink(n)
+	n.referenced = false
+
+	if p.ring.empty() {
+		p.hand = nil
+	}
+}
+
+func (p *clockPolicy[K, V]) victim() *cacheNode[K, V] {
+	for p.hand.referenced {
+		p.hand.referenced = false
+		p.advance()
+	}
+
+	return p.hand
+}
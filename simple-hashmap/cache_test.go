@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test that LRU evicts the least recently used entry
+func TestCache_LRU(t *testing.T) {
+	var evicted []string
+	c, err := NewCache(LRU,
+		WithMaxEntries[string, int](3),
+		WithEvictionCallback(func(key string, _ int) { evicted = append(evicted, key) }),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create Cache: %v", err)
+	}
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+	c.Put("d", 4) // evicts b
+	c.Put("c", 30)
+	c.Put("e", 5) // evicts a
+
+	if !slices.Equal(evicted, []string{"b", "a"}) {
+		t.Errorf("Expected evictions [b a], got %v", evicted)
+	}
+
+	if c.Len() != 3 {
+		t.Errorf("Expected 3 entries, got %d", c.Len())
+	}
+
+	if value, ok := c.Get("c"); !ok || value != 30 {
+		t.Errorf("Expected c to be 30, got (%d, %v)", value, ok)
+	}
+}
+
+// Test that LFU evicts the least frequently used entry, oldest first on ties
+func TestCache_LFU(t *testing.T) {
+	c, err := NewCache(LFU, WithMaxEntries[string, int](3))
+	if err != nil {
+		t.Fatalf("Failed to create Cache: %v", err)
+	}
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+
+	for range 3 {
+		c.Get("a")
+	}
+	c.Get("b")
+	c.Get("c")
+
+	// b and c tie on frequency, b is the least recently used of the two
+	c.Put("d", 4)
+
+	if _, ok := c.Peek("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+
+	// d is now the only entry used once
+	c.Put("e", 5)
+
+	if _, ok := c.Peek("d"); ok {
+		t.Error("Expected d to be evicted")
+	}
+
+	for _, key := range []string{"a", "c", "e"} {
+		if _, ok := c.Peek(key); !ok {
+			t.Errorf("Expected %s to be cached", key)
+		}
+	}
+}
+
+// Test that CLOCK gives referenced entries a second chance
+func TestCache_CLOCK(t *testing.T) {
+	c, err := NewCache(CLOCK, WithMaxEntries[string, int](3))
+	if err != nil {
+		t.Fatalf("Failed to create Cache: %v", err)
+	}
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+
+	// a gets a second chance, so the hand stops at b
+	c.Put("d", 4)
+
+	if _, ok := c.Peek("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+
+	// The hand moved past a, which lost its reference, so c goes next
+	c.Put("e", 5)
+
+	if _, ok := c.Peek("c"); ok {
+		t.Error("Expected c to be evicted")
+	}
+
+	c.Delete("a")
+	c.Delete("d")
+	c.Delete("e")
+
+	if c.Len() != 0 {
+		t.Errorf("Expected empty cache, got %d entries", c.Len())
+	}
+
+	c.Put("f", 6)
+	if value, _ := c.Get("f"); value != 6 {
+		t.Errorf("Expected 6, got %d", value)
+	}
+}
+
+// Test eviction against a byte budget, including a value that grows
+func TestCache_ByteBudget(t *testing.T) {
+	sizeOf := func(key string, value int) int64 { return int64(len(key) + value) }
+	c, err := NewCache(LRU, WithMaxBytes(11, sizeOf))
+	if err != nil {
+		t.Fatalf("Failed to create Cache: %v", err)
+	}
+
+	c.Put("aaa", 1)
+	c.Put("bbb", 1)
+	c.Put("ccc", 1) // evicts aaa
+
+	if c.Bytes() != 8 || c.Len() != 2 {
+		t.Errorf("Expected 8 bytes in 2 entries, got %d in %d", c.Bytes(), c.Len())
+	}
+
+	if err := c.Put("this key is too long", 0); err == nil {
+		t.Error("Expected error for entry over the byte budget, got nil")
+	}
+
+	// Growing bbb makes room by evicting ccc, never bbb itself
+	c.Put("bbb", 5)
+
+	if _, ok := c.Peek("ccc"); ok || c.Bytes() != 8 {
+		t.Errorf("Expected ccc to be evicted leaving 8 bytes, got %d", c.Bytes())
+	}
+
+	if value, _ := c.Peek("bbb"); value != 5 {
+		t.Errorf("Expected bbb to be 5, got %d", value)
+	}
+
+	c.Put("ddddddd", 4) // evicts everything else
+
+	if c.Len() != 1 || c.Bytes() != 11 {
+		t.Errorf("Expected one 11 byte entry, got %d bytes in %d", c.Bytes(), c.Len())
+	}
+}
+
+// Test hit, miss and eviction counts for every policy
+func TestCache_Stats(t *testing.T) {
+	for _, policy := range []EvictionPolicy{LRU, LFU, CLOCK} {
+		c, err := NewCache(policy, WithMaxEntries[string, int](100))
+		if err != nil {
+			t.Fatalf("Failed to create Cache: %v", err)
+		}
+
+		for i := 0; i < 1000; i++ {
+			c.Put(fmt.Sprintf("key%d", i), i)
+		}
+
+		for i := 0; i < 1000; i++ {
+			c.Get(fmt.Sprintf("key%d", i))
+		}
+
+		c.Peek("key999")
+		c.Peek("key0")
+
+		stats := c.Stats()
+		if stats.Hits+stats.Misses != 1000 || stats.Evictions != 900 || c.Len() != 100 {
+			t.Errorf("Policy %d: unexpected stats %+v with %d entries", policy, stats, c.Len())
+		}
+
+		if policy != CLOCK && stats.Hits != 100 {
+			t.Errorf("Policy %d: expected the last 100 keys to hit, got %d hits", policy, stats.Hits)
+		}
+	}
+}
+
+// Test that invalid options and policies are rejected
+func TestCache_InvalidOptions(t *testing.T) {
+	testCases := [][]CacheOption[string, int]{
+		nil,
+		{WithMaxEntries[string, int](0)},
+		{WithMaxBytes[string, int](0, func(string, int) int64 { return 1 })},
+		{WithMaxBytes[string, int](10, nil)},
+		{WithMaxEntries[string, int](1), WithCacheMapOptions[string, int](WithLoadFactor(2))},
+	}
+
+	for _, opts := range testCases {
+		if _, err := NewCache(LRU, opts...); err == nil {
+			t.Error("Expected error for invalid options, got nil")
+		}
+	}
+
+	if _, err := NewCache(EvictionPolicy(7), WithMaxEntries[string, int](1)); err == nil {
+		t.Error("Expected error for invalid policy, got nil")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// appendBinary appends the encoding of v to buf. Strings and byte slices
+// are stored as is and fixed-size numbers little endian; any other type
+// must implement encoding.BinaryMarshaler. Callers length-prefix the result.
+func appendBinary(buf []byte, v any) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		return append(buf, x...), nil
+	case []byte:
+		return append(buf, x...), nil
+	case bool:
+		if x {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case int:
+		return binary.LittleEndian.AppendUint64(buf, uint64(x)), nil
+	case int8:
+		return append(buf, uint8(x)), nil
+	case int16:
+		return binary.LittleEndian.AppendUint16(buf, uint16(x)), nil
+	case int32:
+		return binary.LittleEndian.AppendUint32(buf, uint32(x)), nil
+	case int64:
+		return binary.LittleEndian.AppendUint64(buf, uint64(x)), nil
+	case uint:
+		return binary.LittleEndian.AppendUint64(buf, uint64(x)), nil
+	case uint8:
+		return append(buf, x), nil
+	case uint16:
+		return binary.LittleEndian.AppendUint16(buf, x), nil
+	case uint32:
+		return binary.LittleEndian.AppendUint32(buf, x), nil
+	case uint64:
+		return binary.LittleEndian.AppendUint64(buf, x), nil
+	case float32:
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(x)), nil
+	case float64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(x)), nil
+	case encoding.BinaryMarshaler:
+		data, err := x.MarshalBinary()
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, data...), nil
+	}
+
+	return buf, fmt.Errorf("unsupported type %T", v)
+}
+
+// decodeBinary decodes data produced by appendBinary into dst, which must
+// be a pointer to the original type.
+func decodeBinary(data []byte, dst any) error {
+	switch p := dst.(type) {
+	case *string:
+		*p = string(data)
+	case *[]byte:
+		*p = append([]byte(nil), data...)
+	case *bool:
+		if err := checkWidth(data, 1); err != nil {
+			return err
+		}
+		*p = data[0] != 0
+	case *int:
+		if err := checkWidth(data, 8); err != nil {
+			return err
+		}
+		*p = int(binary.LittleEndian.Uint64(data))
+	case *int8:
+		if err := checkWidth(data, 1); err != nil {
+			return err
+		}
+		*p = int8(data[0])
+	case *int16:
+		if err := checkWidth(data, 2); err != nil {
+			return err
+		}
+		*p = int16(binary.LittleEndian.Uint16(data))
+	case *int32:
+		if err := checkWidth(data, 4); err != nil {
+			return err
+		}
+		*p = int32(binary.LittleEndian.Uint32(data))
+	case *int64:
+		if err := checkWidth(data, 8); err != nil {
+			return err
+		}
+		*p = int64(binary.LittleEndian.Uint64(data))
+	case *uint:
+		if err := checkWidth(data, 8); err != nil {
+			return err
+		}
+		*p = uint(binary.LittleEndian.Uint64(data))
+	case *uint8:
+		if err := checkWidth(data, 1); err != nil {
+			return err
+		}
+		*p = data[0]
+	case *uint16:
+		if err := checkWidth(data, 2); err != nil {
+			return err
+		}
+		*p = binary.LittleEndian.Uint16(data)
+	case *uint32:
+		if err := checkWidth(data, 4); err != nil {
+			return err
+		}
+		*p = binary.LittleEndian.Uint32(data)
+	case *uint64:
+		if err := checkWidth(data, 8); err != nil {
+			return err
+		}
+		*p = binary.LittleEndian.Uint64(data)
+	case *float32:
+		if err := checkWidth(data, 4); err != nil {
+			return err
+		}
+		*p = math.Float32frombits(binary.LittleEndian.Uint32(data))
+	case *float64:
+		if err := checkWidth(data, 8); err != nil {
+			return err
+		}
+		*p = math.Float64frombits(binary.LittleEndian.Uint64(data))
+	case encoding.BinaryUnmarshaler:
+		return p.UnmarshalBinary(data)
+	default:
+		return fmt.Errorf("unsupported type %T", dst)
+	}
+
+	return nil
+}
+
+func checkWidth(data []byte, width int) error {
+	if len(data) != width {
+		return fmt.Errorf("expected %d bytes, got %d", width, len(data))
+	}
+
+	return nil
+}
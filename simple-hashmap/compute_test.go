@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test that Lookup tells a missing key apart from an empty value
+func TestLookup_EmptyValueVsMissingKey(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("emptyValue", "")
+
+	value, ok := hm.Lookup("emptyValue")
+	if !ok {
+		t.Error("Expected key with empty value to be present")
+	}
+	if value != "" {
+		t.Errorf("Expected empty value, got '%s'", value)
+	}
+
+	_, ok = hm.Lookup("missing")
+	if ok {
+		t.Error("Expected missing key to be absent")
+	}
+
+	_, ok = hm.Lookup("")
+	if ok {
+		t.Error("Expected empty key to be absent")
+	}
+}
+
+// Test that GetOrDefault falls back only for missing keys
+func TestGetOrDefault(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("name", "Alice")
+
+	if value := hm.GetOrDefault("name", "nobody"); value != "Alice" {
+		t.Errorf("Expected 'Alice', got '%s'", value)
+	}
+	if value := hm.GetOrDefault("missing", "nobody"); value != "nobody" {
+		t.Errorf("Expected default 'nobody', got '%s'", value)
+	}
+}
+
+// Test Contains for a key with an empty value and a missing key
+func TestContains(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("name", "")
+
+	if !hm.Contains("name") {
+		t.Error("Expected Contains to find 'name'")
+	}
+	if hm.Contains("missing") {
+		t.Error("Expected Contains to miss 'missing'")
+	}
+}
+
+// Test that LoadOrStore stores once and then returns the stored value
+func TestLoadOrStore(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	actual, loaded, err := hm.LoadOrStore("name", "Alice")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded || actual != "Alice" {
+		t.Errorf("Expected ('Alice', false), got ('%s', %v)", actual, loaded)
+	}
+
+	actual, loaded, err = hm.LoadOrStore("name", "Bob")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !loaded || actual != "Alice" {
+		t.Errorf("Expected ('Alice', true), got ('%s', %v)", actual, loaded)
+	}
+
+	if hm.occupied != 1 {
+		t.Errorf("Expected occupied to be 1, got %d", hm.occupied)
+	}
+
+	_, _, err = hm.LoadOrStore("", "value")
+	if err == nil {
+		t.Error("Expected error for empty key, got nil")
+	}
+}
+
+// Test that Compute updates, removes and skips keys as fn decides
+func TestCompute(t *testing.T) {
+	hm, err := NewHashMapOf[string, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	increment := func(value int, ok bool) (int, bool) {
+		return value + 1, true
+	}
+
+	for i := 0; i < 3; i++ {
+		hm.Compute("counter", increment)
+	}
+
+	if value, _ := hm.Get("counter"); value != 3 {
+		t.Errorf("Expected counter to be 3, got %d", value)
+	}
+
+	// Returning keep == false removes the key
+	value, err := hm.Compute("counter", func(value int, ok bool) (int, bool) {
+		if !ok {
+			t.Error("Expected existing key to be passed to fn")
+		}
+		return 0, false
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != 0 || hm.Contains("counter") {
+		t.Error("Expected Compute to remove 'counter'")
+	}
+
+	// Returning keep == false on a missing key is a no-op
+	hm.Compute("missing", func(value int, ok bool) (int, bool) {
+		if ok {
+			t.Error("Expected missing key to be reported as absent")
+		}
+		return 0, false
+	})
+	if hm.occupied != 0 {
+		t.Errorf("Expected occupied to be 0, got %d", hm.occupied)
+	}
+}
+
+// Test that ComputeIfAbsent calls fn only for a missing key
+func TestComputeIfAbsent(t *testing.T) {
+	hm, err := NewHashMapOf[string, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	calls := 0
+
+	length := func(key string) int {
+		calls++
+		return len(key)
+	}
+
+	value, _ := hm.ComputeIfAbsent("hello", length)
+	if value != 5 {
+		t.Errorf("Expected 5, got %d", value)
+	}
+
+	value, _ = hm.ComputeIfAbsent("hello", length)
+	if value != 5 {
+		t.Errorf("Expected 5, got %d", value)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected fn to be called once, got %d", calls)
+	}
+}
+
+// Test that Merge joins new values onto the stored one
+func TestMerge(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	join := func(old, value string) string {
+		return old + "," + value
+	}
+
+	for i := 0; i < 3; i++ {
+		hm.Merge("list", fmt.Sprint(i), join)
+	}
+
+	if value, _ := hm.Get("list"); value != "0,1,2" {
+		t.Errorf("Expected '0,1,2', got '%s'", value)
+	}
+}
+
+// Test compute operations across a rehash
+func TestCompute_TriggersRehash(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		hm.ComputeIfAbsent(i, func(key int) int { return key * key })
+	}
+
+	if hm.size <= 16 {
+		t.Errorf("Expected rehash to occur, size is still %d", hm.size)
+	}
+
+	for i := 0; i < 100; i++ {
+		if value, ok := hm.Lookup(i); !ok || value != i*i {
+			t.Errorf("Key %d: expected %d, got %d (ok=%v)", i, i*i, value, ok)
+		}
+	}
+}
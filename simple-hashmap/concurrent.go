@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"sync"
+)
+
+// ConcurrentHashMap is safe for use by multiple goroutines. Keys are spread
+// over independently locked HashMap shards, so writers to different shards
+// never contend and each shard rehashes on its own. Reads take the shard's
+// read lock and never migrate or reclaim anything, so they do not block
+// each other.
+type ConcurrentHashMap[K comparable, V any] struct {
+	shards []concurrentShard[K, V]
+	shift  uint64 // picks the top bits of the shard hash
+	seed   maphash.Seed
+	hasher Hasher[K]
+	watch  *watchers[K, V] // shared by every shard
+
+	// Set when the shards use WithSeed, so shard choice is reproducible too.
+	fixed     SeededHasher[K]
+	fixedSeed uint64
+}
+
+type concurrentShard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  *HashMap[K, V]
+
+	// Keep neighbouring locks on separate cache lines.
+	_ [64]byte
+}
+
+// NewConcurrentHashMap creates a map with shardCount shards, rounded up to a
+// power of two, that together start with room for initialSize slots. opts
+// apply to every shard. String keys are validated as in NewHashMap.
+func NewConcurrentHashMap[K comparable, V any](shardCount int, initialSize uint64, opts ...Option) (*ConcurrentHashMap[K, V], error) {
+	c, err := NewConcurrentHashMapWithHasher[K, V](shardCount, initialSize, defaultHasher[K](), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if validate, ok := any(validateStringKey).(func(K) error); ok {
+		for i := range c.shards {
+			c.shards[i].m.validate = validate
+		}
+	}
+
+	return c, nil
+}
+
+func NewConcurrentHashMapWithHasher[K comparable, V any](shardCount int, initialSize uint64, hasher Hasher[K], opts ...Option) (*ConcurrentHashMap[K, V], error) {
+	if shardCount < 1 {
+		return nil, errors.New("Invalid shard count.")
+	}
+
+	if initialSize < 1 {
+		return nil, errors.New("Invalid size.")
+	}
+
+	n := nextPowerOfTwo(uint64(shardCount))
+	shardSize := max((initialSize+n-1)/n, 1)
+
+	c := &ConcurrentHashMap[K, V]{
+		shards: make([]concurrentShard[K, V], n),
+		shift:  64 - uint64(bits.TrailingZeros64(n)),
+		seed:   maphash.MakeSeed(),
+		hasher: hasher,
+		watch:  &watchers[K, V]{},
+	}
+
+	for i := range c.shards {
+		m, err := NewHashMapWithHasher[K, V](shardSize, hasher, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		m.watch = c.watch
+		c.shards[i].m = m
+	}
+
+	// The complemented seed keeps shard choice independent of the slot.
+	if m := c.shards[0].m; m.fixed != nil {
+		c.fixed = m.fixed
+		c.fixedSeed = ^m.fixedSeed
+	}
+
+	return c, nil
+}
+
+// shardFor uses its own seed so that the shard choice is independent of the
+// slot a key gets inside the shard.
+func (c *ConcurrentHashMap[K, V]) shardFor(key K) *concurrentShard[K, V] {
+	return &c.shards[c.shardIndex(key)]
+}
+
+func (c *ConcurrentHashMap[K, V]) shardIndex(key K) uint64 {
+	if c.fixed != nil {
+		return c.fixed.HashSeed(c.fixedSeed, key) >> c.shift
+	}
+
+	return c.hasher.Hash(c.seed, key) >> c.shift
+}
+
+func (c *ConcurrentHashMap[K, V]) Put(key K, value V) error {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m.Put(key, value)
+}
+
+func (c *ConcurrentHashMap[K, V]) Get(key K) (V, error) {
+	var zero V
+
+	if err := c.checkKey(key); err != nil {
+		return zero, err
+	}
+
+	value, _ := c.Lookup(key)
+	return value, nil
+}
+
+func (c *ConcurrentHashMap[K, V]) Lookup(key K) (V, bool) {
+	s := c.shardFor(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.m.peek(key)
+}
+
+func (c *ConcurrentHashMap[K, V]) Contains(key K) bool {
+	_, ok := c.Lookup(key)
+	return ok
+}
+
+func (c *ConcurrentHashMap[K, V]) Delete(key K) (bool, error) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m.Delete(key)
+}
+
+func (c *ConcurrentHashMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool, err error) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m.LoadOrStore(key, value)
+}
+
+// Compute runs fn while holding the shard's write lock, so fn must not use
+// the map itself.
+func (c *ConcurrentHashMap[K, V]) Compute(key K, fn func(value V, ok bool) (newValue V, keep bool)) (V, error) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m.Compute(key, fn)
+}
+
+// Len returns the number of entries at a single point in time by holding
+// every shard's read lock while counting.
+func (c *ConcurrentHashMap[K, V]) Len() int {
+	for i := range c.shards {
+		c.shards[i].mu.RLock()
+	}
+
+	total := 0
+	for i := range c.shards {
+		total += c.shards[i].m.Len()
+	}
+
+	for i := range c.shards {
+		c.shards[i].mu.RUnlock()
+	}
+
+	return total
+}
+
+// All iterates over a copy of one shard at a time, so the loop body may use
+// the map. Like sync.Map.Range it does not reflect a single snapshot of the
+// whole map.
+func (c *ConcurrentHashMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var entries []Entry[K, V]
+
+		for i := range c.shards {
+			s := &c.shards[i]
+			entries = entries[:0]
+
+			s.mu.RLock()
+			for entry := range s.m.entries() {
+				entries = append(entries, *entry)
+			}
+			s.mu.RUnlock()
+
+			for _, entry := range entries {
+				if !yield(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
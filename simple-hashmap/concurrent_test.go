@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Test that invalid shard counts, sizes and options are rejected
+func TestNewConcurrentHashMap_Invalid(t *testing.T) {
+	if _, err := NewConcurrentHashMap[string, string](0, 64); err == nil {
+		t.Error("Expected error for zero shards, got nil")
+	}
+	if _, err := NewConcurrentHashMap[string, string](4, 0); err == nil {
+		t.Error("Expected error for zero size, got nil")
+	}
+	if _, err := NewConcurrentHashMap[string, string](4, 64, WithLoadFactor(2)); err == nil {
+		t.Error("Expected shard option errors to be returned, got nil")
+	}
+}
+
+// Test that the shard count is rounded up and every shard gets keys
+func TestConcurrentHashMap_ShardCount(t *testing.T) {
+	c, err := NewConcurrentHashMap[int, int](5, 64)
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	if len(c.shards) != 8 {
+		t.Errorf("Expected 8 shards, got %d", len(c.shards))
+	}
+
+	for i := 0; i < 1000; i++ {
+		c.Put(i, i)
+	}
+
+	// Every shard should have received a share of the keys
+	for i := range c.shards {
+		if c.shards[i].m.Len() == 0 {
+			t.Errorf("Shard %d received no keys", i)
+		}
+	}
+}
+
+// Test that string keys are validated as in NewHashMap
+func TestConcurrentHashMap_InvalidKey(t *testing.T) {
+	c, err := NewConcurrentHashMap[string, string](4, 64)
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	if err := c.Put("", "value"); err == nil {
+		t.Error("Expected error for an empty key, got nil")
+	}
+	if _, err := c.Get(""); err == nil {
+		t.Error("Expected error from Get for an empty key, got nil")
+	}
+
+	if c.Len() != 0 {
+		t.Errorf("Expected no entries, got %d", c.Len())
+	}
+}
+
+// Test concurrent writers on disjoint keys, run with -race
+func TestConcurrentHashMap_ParallelPuts(t *testing.T) {
+	c, err := NewConcurrentHashMap[string, int](16, 16)
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Put(fmt.Sprintf("g%d-key%d", g, i), i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if c.Len() != 8000 {
+		t.Errorf("Expected length 8000, got %d", c.Len())
+	}
+
+	for g := 0; g < 8; g++ {
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("g%d-key%d", g, i)
+			if value, ok := c.Lookup(key); !ok || value != i {
+				t.Fatalf("Key '%s': expected (%d, true), got (%d, %v)", key, i, value, ok)
+			}
+		}
+	}
+}
+
+// Test readers, writers and deleters racing on the same keys
+func TestConcurrentHashMap_MixedWorkload(t *testing.T) {
+	c, err := NewConcurrentHashMap[int, int](4, 16, WithIncrementalRehash(4))
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := (i * 31) % 500
+				switch (g + i) % 4 {
+				case 0:
+					c.Delete(key)
+				case 1:
+					if value, ok := c.Lookup(key); ok && value != key {
+						t.Errorf("Key %d: expected %d, got %d", key, key, value)
+					}
+				case 2:
+					c.Compute(key, func(value int, ok bool) (int, bool) { return key, true })
+				default:
+					c.Put(key, key)
+				}
+			}
+		}(g)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if n := c.Len(); n > 500 {
+				t.Errorf("Expected at most 500 entries, got %d", n)
+			}
+		}
+	}()
+	wg.Wait()
+
+	count := 0
+	for key, value := range c.All() {
+		count++
+		if key != value {
+			t.Errorf("Key %d: expected %d, got %d", key, key, value)
+		}
+	}
+
+	if count != c.Len() {
+		t.Errorf("Expected iteration over %d entries, got %d", c.Len(), count)
+	}
+}
+
+// Test that exactly one LoadOrStore wins per key
+func TestConcurrentHashMap_LoadOrStore(t *testing.T) {
+	c, err := NewConcurrentHashMap[int, int](8, 64)
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	var mu sync.Mutex
+	stored := map[int]int{}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for key := 0; key < 200; key++ {
+				_, loaded, _ := c.LoadOrStore(key, g)
+				if !loaded {
+					mu.Lock()
+					stored[key]++
+					mu.Unlock()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for key := 0; key < 200; key++ {
+		if stored[key] != 1 {
+			t.Errorf("Key %d: expected exactly one store, got %d", key, stored[key])
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"hash/maphash"
+	"math/rand/v2"
+)
+
+// CuckooMap is a bucketized cuckoo hash table. Every key has exactly two
+// candidate buckets, one per seed, each holding cuckooBucketSize slots, so
+// Get inspects at most eight slots no matter how full the table is. Put
+// makes room by moving residents to their alternate bucket; when that
+// displacement path grows past cuckooMaxKicks the table is assumed to
+// contain a cycle and is rebuilt with fresh seeds.
+type CuckooMap[K comparable, V any] struct {
+	buckets  []cuckooBucket[K, V]
+	mask     uint64
+	occupied uint64
+	seeds    [2]maphash.Seed
+	hasher   Hasher[K]
+	rehashes uint64
+}
+
+const (
+	cuckooBucketSize = 4
+	cuckooMaxKicks   = 500
+
+	// Rebuilding with fresh seeds is retried this many times, doubling
+	// the table after each failure, before Put gives up.
+	cuckooMaxRehashes = 8
+
+	// Four-way buckets stay insertable up to roughly 95% load.
+	cuckooMaxLoadNum = 9
+	cuckooMaxLoadDen = 10
+)
+
+type cuckooBucket[K comparable, V any] struct {
+	used   [cuckooBucketSize]bool
+	keys   [cuckooBucketSize]K
+	values [cuckooBucketSize]V
+}
+
+type cuckooEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func NewCuckooMap[K comparable, V any](initialSize uint64) (*CuckooMap[K, V], error) {
+	return NewCuckooMapWithHasher[K, V](initialSize, defaultHasher[K]())
+}
+
+func NewCuckooMapWithHasher[K comparable, V any](initialSize uint64, hasher Hasher[K]) (*CuckooMap[K, V], error) {
+	if initialSize < 1 {
+		return nil, errors.New("Invalid size.")
+	}
+
+	if hasher == nil {
+		return nil, errors.New("Invalid hasher.")
+	}
+
+	c := &CuckooMap[K, V]{hasher: hasher}
+	c.init(nextPowerOfTwo((initialSize + cuckooBucketSize - 1) / cuckooBucketSize))
+	return c, nil
+}
+
+func (c *CuckooMap[K, V]) init(numBuckets uint64) {
+	c.buckets = make([]cuckooBucket[K, V], numBuckets)
+	c.mask = numBuckets - 1
+	c.occupied = 0
+	c.seeds = [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()}
+}
+
+func (c *CuckooMap[K, V]) bucketsFor(key K) (uint64, uint64) {
+	return c.hasher.Hash(c.seeds[0], key) & c.mask, c.hasher.Hash(c.seeds[1], key) & c.mask
+}
+
+// find returns the bucket and slot holding key. It only ever looks at the
+// two candidate buckets.
+func (c *CuckooMap[K, V]) find(key K) (*cuckooBucket[K, V], int, bool) {
+	b1, b2 := c.bucketsFor(key)
+
+	for _, b := range [2]uint64{b1, b2} {
+		bucket := &c.buckets[b]
+
+		for i := range cuckooBucketSize {
+			if bucket.used[i] && c.hasher.Equal(bucket.keys[i], key) {
+				return bucket, i, true
+			}
+		}
+	}
+
+	return nil, 0, false
+}
+
+func (b *cuckooBucket[K, V]) tryPut(key K, value V) bool {
+	for i := range cuckooBucketSize {
+		if !b.used[i] {
+			b.used[i] = true
+			b.keys[i] = key
+			b.values[i] = value
+			return true
+		}
+	}
+
+	return false
+}
+
+// insert places a key that is known to be absent. If the displacement
+// path hits cuckooMaxKicks every move is undone and insert reports false,
+// leaving the table exactly as it was.
+func (c *CuckooMap[K, V]) insert(key K, value V) bool {
+	b1, b2 := c.bucketsFor(key)
+
+	if c.buckets[b1].tryPut(key, value) || c.buckets[b2].tryPut(key, value) {
+		c.occupied++
+		return true
+	}
+
+	type move struct {
+		bucket uint64
+		slot   int
+	}
+
+	path := make([]move, 0, cuckooMaxKicks)
+
+	cur := b1
+	if rand.IntN(2) == 1 {
+		cur = b2
+	}
+
+	for range cuckooMaxKicks {
+		bucket := &c.buckets[cur]
+
+		if bucket.tryPut(key, value) {
+			c.occupied++
+			return true
+		}
+
+		// Evict a random resident and send it to its other bucket.
+		i := rand.IntN(cuckooBucketSize)
+		key, bucket.keys[i] = bucket.keys[i], key
+		value, bucket.values[i] = bucket.values[i], value
+		path = append(path, move{cur, i})
+
+		alt1, alt2 := c.bucketsFor(key)
+		if alt1 == cur {
+			cur = alt2
+		} else {
+			cur = alt1
+		}
+	}
+
+	for j := len(path) - 1; j >= 0; j-- {
+		bucket := &c.buckets[path[j].bucket]
+		i := path[j].slot
+		key, bucket.keys[i] = bucket.keys[i], key
+		value, bucket.values[i] = bucket.values[i], value
+	}
+
+	return false
+}
+
+// rehash rebuilds the table with fresh seeds, adding pending on the way.
+// If no seeds work the old table is kept, without pending, and an error
+// is returned.
+func (c *CuckooMap[K, V]) rehash(numBuckets uint64, pending cuckooEntry[K, V]) error {
+	oldBuckets, oldMask, oldOccupied, oldSeeds := c.buckets, c.mask, c.occupied, c.seeds
+
+	entries := make([]cuckooEntry[K, V], 0, c.occupied+1)
+	for b := range c.buckets {
+		bucket := &c.buckets[b]
+
+		for i := range cuckooBucketSize {
+			if bucket.used[i] {
+				entries = append(entries, cuckooEntry[K, V]{bucket.keys[i], bucket.values[i]})
+			}
+		}
+	}
+	entries = append(entries, pending)
+
+	for attempt := range cuckooMaxRehashes {
+		if attempt > 0 {
+			numBuckets <<= 1
+		}
+
+		c.init(numBuckets)
+		c.rehashes++
+
+		if c.insertAll(entries) {
+			return nil
+		}
+	}
+
+	c.buckets, c.mask, c.occupied, c.seeds = oldBuckets, oldMask, oldOccupied, oldSeeds
+	return errors.New("Cuckoo rehash failed.")
+}
+
+func (c *CuckooMap[K, V]) insertAll(entries []cuckooEntry[K, V]) bool {
+	for _, e := range entries {
+		if !c.insert(e.key, e.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *CuckooMap[K, V]) Put(key K, value V) error {
+	if bucket, i, found := c.find(key); found {
+		bucket.values[i] = value
+		return nil
+	}
+
+	capacity := uint64(len(c.buckets)) * cuckooBucketSize
+	if c.occupied >= capacity*cuckooMaxLoadNum/cuckooMaxLoadDen {
+		return c.rehash(uint64(len(c.buckets))<<1, cuckooEntry[K, V]{key, value})
+	}
+
+	if c.insert(key, value) {
+		return nil
+	}
+
+	// The displacement path looped; a new pair of seeds breaks the cycle.
+	return c.rehash(uint64(len(c.buckets)), cuckooEntry[K, V]{key, value})
+}
+
+func (c *CuckooMap[K, V]) Get(key K) (V, error) {
+	value, _ := c.Lookup(key)
+	return value, nil
+}
+
+func (c *CuckooMap[K, V]) Lookup(key K) (V, bool) {
+	var zero V
+
+	bucket, i, found := c.find(key)
+	if !found {
+		return zero, false
+	}
+
+	return bucket.values[i], true
+}
+
+func (c *CuckooMap[K, V]) Delete(key K) (bool, error) {
+	var zeroKey K
+	var zeroValue V
+
+	bucket, i, found := c.find(key)
+	if !found {
+		return false, nil
+	}
+
+	bucket.used[i] = false
+	bucket.keys[i] = zeroKey
+	bucket.values[i] = zeroValue
+	c.occupied--
+	return true, nil
+}
+
+func (c *CuckooMap[K, V]) Len() int {
+	return int(c.occupied)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"hash/maphash"
+	"testing"
+)
+
+// groupedHasher gives every key in a group of 100 the same hashes, so no
+// choice of seeds can spread a group over more than two buckets.
+type groupedHasher struct{}
+
+func (groupedHasher) Hash(seed maphash.Seed, key int) uint64 {
+	return maphash.Comparable(seed, key/100)
+}
+
+func (groupedHasher) Equal(a, b int) bool {
+	return a == b
+}
+
+// Test that a zero initial size is rejected
+func TestNewCuckooMap_InvalidSize(t *testing.T) {
+	c, err := NewCuckooMap[string, string](0)
+	if err == nil {
+		t.Error("Expected error for initial size 0, got nil")
+	}
+	if c != nil {
+		t.Error("Expected nil CuckooMap for invalid size")
+	}
+}
+
+// Test basic puts, gets, updates and deletes
+func TestCuckooMap_PutGetDelete(t *testing.T) {
+	c, err := NewCuckooMap[string, string](4)
+	if err != nil {
+		t.Fatalf("Failed to create CuckooMap: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		err := c.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+		if err != nil {
+			t.Fatalf("Unexpected error inserting key%d: %v", i, err)
+		}
+	}
+
+	if c.Len() != 1000 {
+		t.Errorf("Expected length 1000, got %d", c.Len())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expectedValue := fmt.Sprintf("value%d", i)
+		if value, _ := c.Get(key); value != expectedValue {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expectedValue, value)
+		}
+	}
+
+	c.Put("key0", "updated")
+	if value, _ := c.Get("key0"); value != "updated" {
+		t.Errorf("Expected 'updated', got '%s'", value)
+	}
+
+	deleted, _ := c.Delete("key1")
+	if !deleted {
+		t.Error("Expected key1 to be deleted")
+	}
+	if _, ok := c.Lookup("key1"); ok {
+		t.Error("Expected key1 to be absent after delete")
+	}
+	if c.Len() != 999 {
+		t.Errorf("Expected length 999, got %d", c.Len())
+	}
+}
+
+// Test that every stored key sits in one of its two candidate buckets
+func TestCuckooMap_KeysStayInCandidateBuckets(t *testing.T) {
+	c, err := NewCuckooMap[int, int](4)
+	if err != nil {
+		t.Fatalf("Failed to create CuckooMap: %v", err)
+	}
+
+	expected := map[int]int{}
+
+	for i := 0; i < 20000; i++ {
+		key := (i * 7919) % 5000
+		if i%4 == 0 {
+			c.Delete(key)
+			delete(expected, key)
+		} else {
+			c.Put(key, i)
+			expected[key] = i
+		}
+	}
+
+	if c.Len() != len(expected) {
+		t.Errorf("Expected length %d, got %d", len(expected), c.Len())
+	}
+
+	for b := range c.buckets {
+		for i := range cuckooBucketSize {
+			if !c.buckets[b].used[i] {
+				continue
+			}
+
+			key := c.buckets[b].keys[i]
+			b1, b2 := c.bucketsFor(key)
+			if uint64(b) != b1 && uint64(b) != b2 {
+				t.Fatalf("Key %d stored in bucket %d, candidates are %d and %d", key, b, b1, b2)
+			}
+		}
+	}
+
+	for key := 0; key < 5000; key++ {
+		value, ok := c.Lookup(key)
+		expectedValue, expectedOk := expected[key]
+		if ok != expectedOk || value != expectedValue {
+			t.Errorf("Key %d: expected (%d, %v), got (%d, %v)", key, expectedValue, expectedOk, value, ok)
+		}
+	}
+}
+
+// Test that an unplaceable key is rejected without losing existing keys
+func TestCuckooMap_RehashFailureKeepsTable(t *testing.T) {
+	c, err := NewCuckooMapWithHasher[int, int](1024, groupedHasher{})
+	if err != nil {
+		t.Fatalf("Failed to create CuckooMap: %v", err)
+	}
+
+	// Two buckets of four slots hold at most eight keys per group
+	for i := 0; i < 8; i++ {
+		if err := c.Put(i, i); err != nil {
+			t.Fatalf("Unexpected error inserting key %d: %v", i, err)
+		}
+	}
+
+	if err := c.Put(8, 8); err == nil {
+		t.Error("Expected error when a ninth key shares both buckets, got nil")
+	}
+
+	if c.Len() != 8 {
+		t.Errorf("Expected length 8, got %d", c.Len())
+	}
+
+	for i := 0; i < 8; i++ {
+		if value, ok := c.Lookup(i); !ok || value != i {
+			t.Errorf("Key %d: expected (%d, true), got (%d, %v)", i, i, value, ok)
+		}
+	}
+
+	if _, ok := c.Lookup(8); ok {
+		t.Error("Expected rejected key to be absent")
+	}
+}
+
+// Test that every backend works through the Table interface
+func TestTable_Backends(t *testing.T) {
+	hashMap, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	swissMap, err := NewSwissMap[string, string](16)
+	if err != nil {
+		t.Fatalf("Failed to create SwissMap: %v", err)
+	}
+
+	cuckooMap, err := NewCuckooMap[string, string](16)
+	if err != nil {
+		t.Fatalf("Failed to create CuckooMap: %v", err)
+	}
+
+	tables := map[string]Table[string, string]{
+		"HashMap":   hashMap,
+		"SwissMap":  swissMap,
+		"CuckooMap": cuckooMap,
+	}
+
+	for name, table := range tables {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				table.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+			}
+			table.Delete("key50")
+
+			if table.Len() != 99 {
+				t.Errorf("Expected length 99, got %d", table.Len())
+			}
+			if value, _ := table.Get("key10"); value != "value10" {
+				t.Errorf("Expected 'value10', got '%s'", value)
+			}
+			if _, ok := table.Lookup("key50"); ok {
+				t.Error("Expected key50 to be absent")
+			}
+		})
+	}
+}
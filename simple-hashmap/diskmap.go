@@ -0,0 +1,533 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// DiskHashMap is an open-addressing hash table that lives on disk, for
+// tables bigger than RAM. The slot array is a memory-mapped file of
+// fixed-size slots, and every slot points at a record in an append-only
+// heap file holding the encoded key and value. Only the slots touched by a
+// probe and the records read by a lookup are paged in.
+//
+// Slots use linear probing with backward-shift deletion and the table
+// doubles at the same load factor as HashMap. Each slot stores the full
+// hash of its key, so growing never reads the heap, and reopening a table
+// maps the existing slot file without any rebuild.
+//
+// Keys are hashed with FNV-1a over their encoding rather than maphash,
+// because the layout has to stay valid across processes. That makes the
+// table open to hash flooding, so it should not index untrusted keys.
+//
+// Writes reach the files through the page cache; call Sync to make them
+// durable. A DiskHashMap is not safe for concurrent use.
+type DiskHashMap[K comparable, V any] struct {
+	slots    *os.File
+	heap     *os.File
+	data     []byte // mapped slot file
+	path     string
+	mask     uint64
+	count    uint64
+	maxLoad  uint64
+	heapSize int64
+	buf      []byte
+}
+
+// Slot file layout, all integers little endian:
+//
+//	magic     "HDSK"
+//	version   uint8, padded to 8 bytes
+//	capacity  uint64
+//	count     uint64
+//	slots of
+//	  hash       uint64
+//	  offset     uint64, record offset in the heap, 0 for an empty slot
+//	  key size   uint32
+//	  value size uint32
+//
+// The heap file starts with "HHEP" and a version byte, so no record sits
+// at offset 0.
+const (
+	diskMagic     = "HDSK"
+	diskHeapMagic = "HHEP"
+	diskVersion   = 1
+
+	diskHeaderSize = 24
+	diskSlotSize   = 24
+	diskHeapHeader = 8
+
+	diskMaxLoadNum = 3
+	diskMaxLoadDen = 4
+)
+
+var ErrInvalidDiskMap = errors.New("invalid HashMap file")
+
+var _ Table[string, string] = (*DiskHashMap[string, string])(nil)
+
+// OpenDiskHashMap opens the table stored at path and path+".heap", creating
+// it with room for initialSize slots if it does not exist yet.
+func OpenDiskHashMap[K comparable, V any](path string, initialSize uint64) (*DiskHashMap[K, V], error) {
+	if initialSize < 1 {
+		return nil, errors.New("Invalid size.")
+	}
+
+	d := &DiskHashMap[K, V]{path: path}
+
+	var err error
+	if d.heap, err = os.OpenFile(path+".heap", os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		return nil, err
+	}
+
+	if err := d.openHeap(); err != nil {
+		d.heap.Close()
+		return nil, err
+	}
+
+	if d.slots, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644); err != nil {
+		d.heap.Close()
+		return nil, err
+	}
+
+	if err := d.openSlots(nextPowerOfTwo(initialSize)); err != nil {
+		d.slots.Close()
+		d.heap.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *DiskHashMap[K, V]) openHeap() error {
+	info, err := d.heap.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		header := make([]byte, diskHeapHeader)
+		copy(header, diskHeapMagic)
+		header[len(diskHeapMagic)] = diskVersion
+
+		if _, err := d.heap.WriteAt(header, 0); err != nil {
+			return err
+		}
+
+		d.heapSize = diskHeapHeader
+		return nil
+	}
+
+	header := make([]byte, diskHeapHeader)
+	if _, err := d.heap.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("%w: short heap header", ErrInvalidDiskMap)
+	}
+
+	if string(header[:len(diskHeapMagic)]) != diskHeapMagic || header[len(diskHeapMagic)] != diskVersion {
+		return fmt.Errorf("%w: bad heap header", ErrInvalidDiskMap)
+	}
+
+	d.heapSize = info.Size()
+	return nil
+}
+
+func (d *DiskHashMap[K, V]) openSlots(capacity uint64) error {
+	info, err := d.slots.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		data, err := createSlotFile(d.slots, capacity)
+		if err != nil {
+			return err
+		}
+
+		d.setTable(data)
+		return nil
+	}
+
+	if info.Size() < diskHeaderSize {
+		return fmt.Errorf("%w: short header", ErrInvalidDiskMap)
+	}
+
+	data, err := syscall.Mmap(int(d.slots.Fd()), 0, int(info.Size()), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+
+	if string(data[:len(diskMagic)]) != diskMagic || data[len(diskMagic)] != diskVersion {
+		syscall.Munmap(data)
+		return fmt.Errorf("%w: bad header", ErrInvalidDiskMap)
+	}
+
+	capacity = binary.LittleEndian.Uint64(data[8:])
+	if capacity == 0 || capacity&(capacity-1) != 0 || int64(diskHeaderSize+capacity*diskSlotSize) != info.Size() {
+		syscall.Munmap(data)
+		return fmt.Errorf("%w: bad capacity %d", ErrInvalidDiskMap, capacity)
+	}
+
+	if count := binary.LittleEndian.Uint64(data[16:]); count >= capacity {
+		syscall.Munmap(data)
+		return fmt.Errorf("%w: bad count %d", ErrInvalidDiskMap, count)
+	}
+
+	d.setTable(data)
+	return nil
+}
+
+// createSlotFile sizes f for capacity empty slots and maps it.
+func createSlotFile(f *os.File, capacity uint64) ([]byte, error) {
+	size := int64(diskHeaderSize + capacity*diskSlotSize)
+	if err := f.Truncate(size); err != nil {
+		return nil, err
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	copy(data, diskMagic)
+	data[len(diskMagic)] = diskVersion
+	binary.LittleEndian.PutUint64(data[8:], capacity)
+	binary.LittleEndian.PutUint64(data[16:], 0)
+	return data, nil
+}
+
+func (d *DiskHashMap[K, V]) setTable(data []byte) {
+	capacity := binary.LittleEndian.Uint64(data[8:])
+
+	d.data = data
+	d.mask = capacity - 1
+	d.count = binary.LittleEndian.Uint64(data[16:])
+	// Rounding down keeps at least one slot empty, which ends every probe.
+	d.maxLoad = capacity * diskMaxLoadNum / diskMaxLoadDen
+}
+
+// diskSlot is a view of one slot in the mapped file.
+type diskSlot []byte
+
+func (d *DiskHashMap[K, V]) slot(i uint64) diskSlot {
+	start := diskHeaderSize + i*diskSlotSize
+	return diskSlot(d.data[start : start+diskSlotSize])
+}
+
+func (s diskSlot) hash() uint64      { return binary.LittleEndian.Uint64(s) }
+func (s diskSlot) offset() int64     { return int64(binary.LittleEndian.Uint64(s[8:])) }
+func (s diskSlot) keySize() uint32   { return binary.LittleEndian.Uint32(s[16:]) }
+func (s diskSlot) valueSize() uint32 { return binary.LittleEndian.Uint32(s[20:]) }
+func (s diskSlot) empty() bool       { return s.offset() == 0 }
+
+func (s diskSlot) set(hash uint64, offset int64, keySize, valueSize uint32) {
+	binary.LittleEndian.PutUint64(s, hash)
+	binary.LittleEndian.PutUint64(s[8:], uint64(offset))
+	binary.LittleEndian.PutUint32(s[16:], keySize)
+	binary.LittleEndian.PutUint32(s[20:], valueSize)
+}
+
+func (s diskSlot) clear() {
+	clear(s)
+}
+
+func (d *DiskHashMap[K, V]) setCount(count uint64) {
+	d.count = count
+	binary.LittleEndian.PutUint64(d.data[16:], count)
+}
+
+// diskHash is the hash of WithSeed with seed 0.
+func diskHash(key []byte) uint64 {
+	return hashBytes(0, key)
+}
+
+// find returns the slot holding the encoded key, or the empty slot that
+// ends its probe sequence. The load limit keeps a slot empty, so a full
+// table means the file was damaged.
+func (d *DiskHashMap[K, V]) find(key []byte, hash uint64) (uint64, bool, error) {
+	i := hash & d.mask
+
+	for range d.mask + 1 {
+		s := d.slot(i)
+		if s.empty() {
+			return i, false, nil
+		}
+
+		if s.hash() == hash && int(s.keySize()) == len(key) {
+			stored := make([]byte, len(key))
+			if _, err := d.heap.ReadAt(stored, s.offset()); err != nil {
+				return 0, false, err
+			}
+
+			if bytes.Equal(stored, key) {
+				return i, true, nil
+			}
+		}
+
+		i = (i + 1) & d.mask
+	}
+
+	return 0, false, fmt.Errorf("%w: no empty slot", ErrInvalidDiskMap)
+}
+
+func (d *DiskHashMap[K, V]) encodeKey(key K) ([]byte, error) {
+	buf, err := appendBinary(d.buf[:0], key)
+	d.buf = buf
+	if err != nil {
+		return nil, fmt.Errorf("encoding key: %w", err)
+	}
+
+	if uint64(len(buf)) > 1<<32-1 {
+		return nil, errors.New("Key too large.")
+	}
+
+	return buf, nil
+}
+
+func (d *DiskHashMap[K, V]) Put(key K, value V) error {
+	if d.data == nil {
+		return os.ErrClosed
+	}
+
+	record, err := d.encodeKey(key)
+	if err != nil {
+		return err
+	}
+	keySize := len(record)
+
+	if record, err = appendBinary(record, value); err != nil {
+		return fmt.Errorf("encoding value: %w", err)
+	}
+	d.buf = record
+
+	valueSize := len(record) - keySize
+	if uint64(valueSize) > 1<<32-1 {
+		return errors.New("Value too large.")
+	}
+
+	hash := diskHash(record[:keySize])
+
+	i, found, err := d.find(record[:keySize], hash)
+	if err != nil {
+		return err
+	}
+
+	if !found && d.count >= d.maxLoad {
+		if err := d.grow(); err != nil {
+			return err
+		}
+
+		i, _, err = d.find(record[:keySize], hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The record is written before the slot points at it. An overwrite
+	// leaves the old record behind as garbage in the heap.
+	offset := d.heapSize
+	if _, err := d.heap.WriteAt(record, offset); err != nil {
+		return err
+	}
+	d.heapSize += int64(len(record))
+
+	d.slot(i).set(hash, offset, uint32(keySize), uint32(valueSize))
+	if !found {
+		d.setCount(d.count + 1)
+	}
+
+	return nil
+}
+
+func (d *DiskHashMap[K, V]) Get(key K) (V, error) {
+	value, _, err := d.lookup(key)
+	return value, err
+}
+
+// Lookup reports a key as missing if it cannot be read; use Get to see
+// the error.
+func (d *DiskHashMap[K, V]) Lookup(key K) (V, bool) {
+	value, ok, err := d.lookup(key)
+	return value, ok && err == nil
+}
+
+func (d *DiskHashMap[K, V]) Contains(key K) bool {
+	_, ok := d.Lookup(key)
+	return ok
+}
+
+func (d *DiskHashMap[K, V]) lookup(key K) (V, bool, error) {
+	var value V
+
+	if d.data == nil {
+		return value, false, os.ErrClosed
+	}
+
+	encoded, err := d.encodeKey(key)
+	if err != nil {
+		return value, false, err
+	}
+
+	i, found, err := d.find(encoded, diskHash(encoded))
+	if err != nil || !found {
+		return value, false, err
+	}
+
+	s := d.slot(i)
+	data := make([]byte, s.valueSize())
+	if _, err := d.heap.ReadAt(data, s.offset()+int64(s.keySize())); err != nil {
+		return value, false, err
+	}
+
+	if err := decodeBinary(data, &value); err != nil {
+		return value, false, fmt.Errorf("%w: %v", ErrInvalidDiskMap, err)
+	}
+
+	return value, true, nil
+}
+
+// Delete uses backward shift like HashMap under linear probing, so the
+// slot file never holds tombstones.
+func (d *DiskHashMap[K, V]) Delete(key K) (bool, error) {
+	if d.data == nil {
+		return false, os.ErrClosed
+	}
+
+	encoded, err := d.encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	i, found, err := d.find(encoded, diskHash(encoded))
+	if err != nil || !found {
+		return false, err
+	}
+
+	// Move back every later entry in the cluster whose home slot does not
+	// lie between the hole and its current slot.
+	for j := (i + 1) & d.mask; ; j = (j + 1) & d.mask {
+		next := d.slot(j)
+		if next.empty() {
+			break
+		}
+
+		if (j-next.hash())&d.mask >= (j-i)&d.mask {
+			copy(d.slot(i), next)
+			i = j
+		}
+	}
+
+	d.slot(i).clear()
+	d.setCount(d.count - 1)
+	return true, nil
+}
+
+func (d *DiskHashMap[K, V]) Len() int {
+	return int(d.count)
+}
+
+// grow doubles the slot file. The new table is built next to the old one
+// and renamed over it, so a crash leaves one of the two intact.
+func (d *DiskHashMap[K, V]) grow() error {
+	capacity := (d.mask + 1) * 2
+	tmpPath := d.path + ".grow"
+
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	data, err := createSlotFile(f, capacity)
+	if err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	next := &DiskHashMap[K, V]{data: data, mask: capacity - 1}
+	for i := uint64(0); i <= d.mask; i++ {
+		s := d.slot(i)
+		if s.empty() {
+			continue
+		}
+
+		j := s.hash() & next.mask
+		for !next.slot(j).empty() {
+			j = (j + 1) & next.mask
+		}
+
+		copy(next.slot(j), s)
+	}
+	next.setCount(d.count)
+
+	if err = msync(data); err == nil {
+		err = os.Rename(tmpPath, d.path)
+	}
+	if err != nil {
+		syscall.Munmap(data)
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// The new slot file is at d.path from here on, so it must be used even
+	// if making the rename durable fails.
+	syscall.Munmap(d.data)
+	d.slots.Close()
+
+	d.slots = f
+	d.setTable(data)
+	return syncDir(filepath.Dir(d.path))
+}
+
+// Sync flushes the heap and then the slot file to disk.
+func (d *DiskHashMap[K, V]) Sync() error {
+	if d.data == nil {
+		return os.ErrClosed
+	}
+
+	if err := d.heap.Sync(); err != nil {
+		return err
+	}
+
+	return msync(d.data)
+}
+
+// Close syncs and unmaps the table.
+func (d *DiskHashMap[K, V]) Close() error {
+	if d.data == nil {
+		return nil
+	}
+
+	err := d.Sync()
+
+	if unmapErr := syscall.Munmap(d.data); err == nil {
+		err = unmapErr
+	}
+	d.data = nil
+
+	if closeErr := d.slots.Close(); err == nil {
+		err = closeErr
+	}
+
+	if closeErr := d.heap.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func msync(data []byte) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test Put, Get, Delete and growth of the slot file
+func TestDiskHashMap_Basic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table")
+	d, err := OpenDiskHashMap[string, int](path, 4)
+	if err != nil {
+		t.Fatalf("Failed to open DiskHashMap: %v", err)
+	}
+	defer d.Close()
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		if err := d.Put(fmt.Sprintf("key%d", i), i); err != nil {
+			t.Fatalf("Failed to put key%d: %v", i, err)
+		}
+	}
+
+	if d.Len() != n {
+		t.Errorf("Expected %d entries, got %d", n, d.Len())
+	}
+
+	if capacity := d.mask + 1; capacity < n*diskMaxLoadDen/diskMaxLoadNum {
+		t.Errorf("Expected slot file to grow past load factor, capacity is %d", capacity)
+	}
+
+	for i := 0; i < n; i += 2 {
+		if deleted, err := d.Delete(fmt.Sprintf("key%d", i)); !deleted || err != nil {
+			t.Fatalf("Failed to delete key%d: %v %v", i, deleted, err)
+		}
+	}
+
+	d.Put("key1", -1)
+
+	for i := 0; i < n; i++ {
+		value, ok := d.Lookup(fmt.Sprintf("key%d", i))
+
+		switch {
+		case i%2 == 0 && ok:
+			t.Errorf("Expected key%d to be deleted", i)
+		case i == 1 && value != -1:
+			t.Errorf("Expected overwritten value -1, got %d", value)
+		case i%2 == 1 && i != 1 && (!ok || value != i):
+			t.Errorf("Expected key%d to be %d, got (%d, %v)", i, i, value, ok)
+		}
+	}
+
+	if d.Len() != n/2 {
+		t.Errorf("Expected %d entries, got %d", n/2, d.Len())
+	}
+}
+
+// Test that a one-slot table grows before it fills, so a miss still ends
+func TestDiskHashMap_SingleSlot(t *testing.T) {
+	d, err := OpenDiskHashMap[string, int](filepath.Join(t.TempDir(), "table"), 1)
+	if err != nil {
+		t.Fatalf("Failed to open DiskHashMap: %v", err)
+	}
+	defer d.Close()
+
+	d.Put("a", 1)
+
+	if _, ok := d.Lookup("b"); ok {
+		t.Error("Expected missing key to be absent")
+	}
+	if value, _ := d.Get("a"); value != 1 {
+		t.Errorf("Expected 1, got %d", value)
+	}
+}
+
+// Test that a grow whose rename fails keeps the old slot file
+func TestDiskHashMap_GrowRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table")
+	d, err := OpenDiskHashMap[string, int](path, 4)
+	if err != nil {
+		t.Fatalf("Failed to open DiskHashMap: %v", err)
+	}
+	defer d.Close()
+
+	for i := 0; i < 3; i++ {
+		d.Put(fmt.Sprintf("key%d", i), i)
+	}
+
+	// A file cannot be renamed over a non-empty directory
+	os.Remove(path)
+	os.Mkdir(path, 0o755)
+	os.WriteFile(filepath.Join(path, "block"), nil, 0o644)
+
+	if err := d.Put("key3", 3); err == nil {
+		t.Error("Expected error when the grown slot file cannot be renamed, got nil")
+	}
+
+	if capacity := d.mask + 1; capacity != 4 {
+		t.Errorf("Expected the table to keep capacity 4, got %d", capacity)
+	}
+	if _, err := os.Stat(path + ".grow"); !os.IsNotExist(err) {
+		t.Errorf("Expected the grown slot file to be removed, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if value, ok := d.Lookup(fmt.Sprintf("key%d", i)); !ok || value != i {
+			t.Errorf("Expected key%d to be %d, got (%d, %v)", i, i, value, ok)
+		}
+	}
+}
+
+// Test that reopening maps the existing slot file as is
+func TestDiskHashMap_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table")
+
+	d, err := OpenDiskHashMap[string, int](path, 8)
+	if err != nil {
+		t.Fatalf("Failed to open DiskHashMap: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		d.Put(fmt.Sprintf("key%d", i), i)
+	}
+	d.Delete("key0")
+	capacity := d.mask + 1
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".grow"); !os.IsNotExist(err) {
+		t.Errorf("Expected no leftover grow file, got %v", err)
+	}
+
+	// initialSize only applies to new tables
+	d, err = OpenDiskHashMap[string, int](path, 1<<20)
+	if err != nil {
+		t.Fatalf("Failed to open DiskHashMap: %v", err)
+	}
+	defer d.Close()
+
+	if d.mask+1 != capacity {
+		t.Errorf("Expected capacity %d after reopen, got %d", capacity, d.mask+1)
+	}
+
+	if d.Len() != 99 {
+		t.Errorf("Expected 99 entries after reopen, got %d", d.Len())
+	}
+
+	for i := 1; i < 100; i++ {
+		if value, _ := d.Get(fmt.Sprintf("key%d", i)); value != i {
+			t.Errorf("Expected key%d to be %d, got %d", i, i, value)
+		}
+	}
+
+	if d.Contains("key0") {
+		t.Error("Expected deleted key to stay deleted after reopen")
+	}
+}
+
+// Test that a zero size and a file that is not a table are rejected
+func TestDiskHashMap_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := OpenDiskHashMap[string, int](filepath.Join(dir, "zero"), 0); err == nil {
+		t.Error("Expected error for size 0, got nil")
+	}
+
+	path := filepath.Join(dir, "garbage")
+	os.WriteFile(path, make([]byte, 100), 0o644)
+
+	if _, err := OpenDiskHashMap[string, int](path, 8); err == nil {
+		t.Error("Expected error for a file that is not a table, got nil")
+	}
+}
+
+// Test that a closed table refuses reads and writes
+func TestDiskHashMap_Closed(t *testing.T) {
+	d, err := OpenDiskHashMap[string, int](filepath.Join(t.TempDir(), "table"), 8)
+	if err != nil {
+		t.Fatalf("Failed to open DiskHashMap: %v", err)
+	}
+
+	d.Close()
+
+	if err := d.Put("key", 1); err == nil {
+		t.Error("Expected error writing to a closed table, got nil")
+	}
+
+	if _, err := d.Get("key"); err == nil {
+		t.Error("Expected error reading a closed table, got nil")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"hash/maphash"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	x, y int
+}
+
+// caseInsensitiveHasher treats keys differing only in case as equal
+type caseInsensitiveHasher struct{}
+
+func (caseInsensitiveHasher) Hash(seed maphash.Seed, key string) uint64 {
+	return maphash.String(seed, strings.ToLower(key))
+}
+
+func (caseInsensitiveHasher) Equal(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// Test integer keys with struct values
+func TestHashMapOf_IntKeys(t *testing.T) {
+	hm, err := NewHashMapOf[int, point](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	// Zero is a valid key for non-string maps
+	for i := 0; i < 100; i++ {
+		err := hm.Put(i, point{i, -i})
+		if err != nil {
+			t.Fatalf("Unexpected error inserting key %d: %v", i, err)
+		}
+	}
+
+	if hm.occupied != 100 {
+		t.Errorf("Expected occupied to be 100, got %d", hm.occupied)
+	}
+
+	for i := 0; i < 100; i++ {
+		value, err := hm.Get(i)
+		if err != nil {
+			t.Errorf("Unexpected error getting key %d: %v", i, err)
+		}
+		if value != (point{i, -i}) {
+			t.Errorf("Key %d: expected %v, got %v", i, point{i, -i}, value)
+		}
+	}
+}
+
+// Test composite keys hashed through maphash.Comparable
+func TestHashMapOf_StructKeys(t *testing.T) {
+	hm, err := NewHashMapOf[point, string](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			hm.Put(point{x, y}, fmt.Sprintf("%d,%d", x, y))
+		}
+	}
+
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			value, _ := hm.Get(point{x, y})
+			expectedValue := fmt.Sprintf("%d,%d", x, y)
+			if value != expectedValue {
+				t.Errorf("Key %v: expected '%s', got '%s'", point{x, y}, expectedValue, value)
+			}
+		}
+	}
+
+	deleted, _ := hm.Delete(point{3, 4})
+	if !deleted {
+		t.Error("Expected Delete to report the key as present")
+	}
+	if value, _ := hm.Get(point{3, 4}); value != "" {
+		t.Errorf("Expected empty value for deleted key, got '%s'", value)
+	}
+}
+
+// Test that a custom Hasher decides key equality
+func TestHashMapWithHasher_CustomEquality(t *testing.T) {
+	hm, err := NewHashMapWithHasher[string, int](16, caseInsensitiveHasher{})
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("Name", 1)
+	hm.Put("NAME", 2)
+
+	if hm.occupied != 1 {
+		t.Errorf("Expected occupied to be 1, got %d", hm.occupied)
+	}
+
+	value, _ := hm.Get("name")
+	if value != 2 {
+		t.Errorf("Expected 2, got %d", value)
+	}
+}
+
+// Test that a nil Hasher is rejected
+func TestHashMapWithHasher_NilHasher(t *testing.T) {
+	hm, err := NewHashMapWithHasher[string, int](16, nil)
+	if err == nil {
+		t.Error("Expected error for nil hasher, got nil")
+	}
+	if hm != nil {
+		t.Error("Expected nil HashMap for nil hasher")
+	}
+}
+
+// Test that string maps pick the string hasher by default
+func TestHashMapOf_DefaultHasher(t *testing.T) {
+	strMap, err := NewHashMapOf[string, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if _, ok := strMap.hasher.(StringHasher); !ok {
+		t.Errorf("Expected StringHasher for string keys, got %T", strMap.hasher)
+	}
+
+	intMap, err := NewHashMapOf[int, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if _, ok := intMap.hasher.(ComparableHasher[int]); !ok {
+		t.Errorf("Expected ComparableHasher for int keys, got %T", intMap.hasher)
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"errors"
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// PersistentMap is an immutable hash array mapped trie. Put and Delete
+// return a new map and leave the receiver untouched, copying only the
+// nodes on the path to the changed key, so old versions stay valid and
+// cheap to keep. A PersistentMap is safe for concurrent reads.
+//
+// Each trie level consumes 5 bits of the key's hash to pick one of 32
+// children, and a node stores only its present children behind a bitmap.
+// Keys whose 64-bit hashes are equal end up in a collision node.
+type PersistentMap[K comparable, V any] struct {
+	root   *hamtNode[K, V]
+	size   int
+	seed   maphash.Seed
+	hasher Hasher[K]
+}
+
+const (
+	hamtBits = 5
+	hamtMask = 1<<hamtBits - 1
+)
+
+// hamtEdit marks nodes owned by one PersistentMapBuilder, which may change
+// them in place. Nodes of a finished map have an edit no builder holds.
+type hamtEdit struct{ _ byte }
+
+type hamtNode[K comparable, V any] struct {
+	bitmap    uint32
+	entries   []hamtEntry[K, V]
+	collision bool // entries all share one hash and bitmap is unused
+	edit      *hamtEdit
+}
+
+// hamtEntry is a subtree when node is set and a key/value leaf otherwise.
+type hamtEntry[K comparable, V any] struct {
+	node  *hamtNode[K, V]
+	key   K
+	value V
+	hash  uint64
+}
+
+func NewPersistentMap[K comparable, V any]() *PersistentMap[K, V] {
+	m, _ := NewPersistentMapWithHasher[K, V](defaultHasher[K]())
+	return m
+}
+
+func NewPersistentMapWithHasher[K comparable, V any](hasher Hasher[K]) (*PersistentMap[K, V], error) {
+	if hasher == nil {
+		return nil, errors.New("Invalid hasher.")
+	}
+
+	return &PersistentMap[K, V]{
+		root:   &hamtNode[K, V]{},
+		seed:   maphash.MakeSeed(),
+		hasher: hasher,
+	}, nil
+}
+
+func (m *PersistentMap[K, V]) hash(key K) uint64 {
+	return m.hasher.Hash(m.seed, key)
+}
+
+func (m *PersistentMap[K, V]) with(root *hamtNode[K, V], size int) *PersistentMap[K, V] {
+	return &PersistentMap[K, V]{root: root, size: size, seed: m.seed, hasher: m.hasher}
+}
+
+func (m *PersistentMap[K, V]) Lookup(key K) (V, bool) {
+	var zero V
+
+	hash := m.hash(key)
+	n := m.root
+
+	for shift := uint(0); ; shift += hamtBits {
+		if n.collision {
+			for _, e := range n.entries {
+				if m.hasher.Equal(e.key, key) {
+					return e.value, true
+				}
+			}
+
+			return zero, false
+		}
+
+		bit := uint32(1) << (hash >> shift & hamtMask)
+		if n.bitmap&bit == 0 {
+			return zero, false
+		}
+
+		e := &n.entries[bits.OnesCount32(n.bitmap&(bit-1))]
+		if e.node == nil {
+			if e.hash == hash && m.hasher.Equal(e.key, key) {
+				return e.value, true
+			}
+
+			return zero, false
+		}
+
+		n = e.node
+	}
+}
+
+func (m *PersistentMap[K, V]) Contains(key K) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+func (m *PersistentMap[K, V]) Len() int {
+	return m.size
+}
+
+// Put returns a map with key set to value.
+func (m *PersistentMap[K, V]) Put(key K, value V) *PersistentMap[K, V] {
+	root, added := m.insert(m.root, 0, hamtEntry[K, V]{key: key, value: value, hash: m.hash(key)}, nil)
+
+	size := m.size
+	if added {
+		size++
+	}
+
+	return m.with(root, size)
+}
+
+// Delete returns a map without key, or m itself if key is absent.
+func (m *PersistentMap[K, V]) Delete(key K) *PersistentMap[K, V] {
+	root, removed := m.remove(m.root, 0, m.hash(key), key, nil)
+	if !removed {
+		return m
+	}
+
+	return m.with(root, m.size-1)
+}
+
+// All iterates over every key/value pair in hash order.
+func (m *PersistentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.walk(yield)
+	}
+}
+
+func (n *hamtNode[K, V]) walk(yield func(K, V) bool) bool {
+	for i := range n.entries {
+		e := &n.entries[i]
+
+		if e.node != nil {
+			if !e.node.walk(yield) {
+				return false
+			}
+		} else if !yield(e.key, e.value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// editable returns n itself if edit owns it, otherwise a copy owned by
+// edit. A nil edit always copies.
+func (n *hamtNode[K, V]) editable(edit *hamtEdit) *hamtNode[K, V] {
+	if edit != nil && n.edit == edit {
+		return n
+	}
+
+	return &hamtNode[K, V]{
+		bitmap:    n.bitmap,
+		entries:   slices.Clone(n.entries),
+		collision: n.collision,
+		edit:      edit,
+	}
+}
+
+func (m *PersistentMap[K, V]) insert(n *hamtNode[K, V], shift uint, leaf hamtEntry[K, V], edit *hamtEdit) (*hamtNode[K, V], bool) {
+	if n.collision {
+		for i := range n.entries {
+			if m.hasher.Equal(n.entries[i].key, leaf.key) {
+				n = n.editable(edit)
+				n.entries[i] = leaf
+				return n, false
+			}
+		}
+
+		n = n.editable(edit)
+		n.entries = append(n.entries, leaf)
+		return n, true
+	}
+
+	bit := uint32(1) << (leaf.hash >> shift & hamtMask)
+	i := bits.OnesCount32(n.bitmap & (bit - 1))
+
+	if n.bitmap&bit == 0 {
+		n = n.editable(edit)
+		n.bitmap |= bit
+		n.entries = slices.Insert(n.entries, i, leaf)
+		return n, true
+	}
+
+	e := n.entries[i]
+
+	switch {
+	case e.node != nil:
+		child, added := m.insert(e.node, shift+hamtBits, leaf, edit)
+		if child == e.node {
+			return n, added
+		}
+
+		n = n.editable(edit)
+		n.entries[i] = hamtEntry[K, V]{node: child}
+		return n, added
+	case e.hash == leaf.hash && m.hasher.Equal(e.key, leaf.key):
+		n = n.editable(edit)
+		n.entries[i] = leaf
+		return n, false
+	}
+
+	n = n.editable(edit)
+	n.entries[i] = hamtEntry[K, V]{node: mergeLeaves(shift+hamtBits, e, leaf, edit)}
+	return n, true
+}
+
+// mergeLeaves builds the subtree holding two leaves that share a slot.
+func mergeLeaves[K comparable, V any](shift uint, a, b hamtEntry[K, V], edit *hamtEdit) *hamtNode[K, V] {
+	if shift >= 64 {
+		return &hamtNode[K, V]{entries: []hamtEntry[K, V]{a, b}, collision: true, edit: edit}
+	}
+
+	ia, ib := a.hash>>shift&hamtMask, b.hash>>shift&hamtMask
+
+	if ia == ib {
+		child := mergeLeaves(shift+hamtBits, a, b, edit)
+		return &hamtNode[K, V]{bitmap: 1 << ia, entries: []hamtEntry[K, V]{{node: child}}, edit: edit}
+	}
+
+	if ia > ib {
+		a, b = b, a
+	}
+
+	return &hamtNode[K, V]{bitmap: 1<<ia | 1<<ib, entries: []hamtEntry[K, V]{a, b}, edit: edit}
+}
+
+// remove deletes key below n. A subtree left with a single leaf is replaced
+// by that leaf, so the trie never keeps chains of one-entry nodes.
+func (m *PersistentMap[K, V]) remove(n *hamtNode[K, V], shift uint, hash uint64, key K, edit *hamtEdit) (*hamtNode[K, V], bool) {
+	if n.collision {
+		for i := range n.entries {
+			if m.hasher.Equal(n.entries[i].key, key) {
+				n = n.editable(edit)
+				n.entries = slices.Delete(n.entries, i, i+1)
+				return n, true
+			}
+		}
+
+		return n, false
+	}
+
+	bit := uint32(1) << (hash >> shift & hamtMask)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	i := bits.OnesCount32(n.bitmap & (bit - 1))
+	e := n.entries[i]
+
+	if e.node == nil {
+		if e.hash != hash || !m.hasher.Equal(e.key, key) {
+			return n, false
+		}
+
+		n = n.editable(edit)
+		n.bitmap &^= bit
+		n.entries = slices.Delete(n.entries, i, i+1)
+		return n, true
+	}
+
+	child, removed := m.remove(e.node, shift+hamtBits, hash, key, edit)
+	if !removed {
+		return n, false
+	}
+
+	n = n.editable(edit)
+
+	if len(child.entries) == 1 && child.entries[0].node == nil {
+		n.entries[i] = child.entries[0]
+	} else {
+		n.entries[i] = hamtEntry[K, V]{node: child}
+	}
+
+	return n, true
+}
+
+// PersistentMapBuilder is the transient form of a PersistentMap for bulk
+// changes. Nodes it creates belong to it and are updated in place by later
+// calls, instead of being copied per change. A builder is not safe for
+// concurrent use.
+type PersistentMapBuilder[K comparable, V any] struct {
+	m    PersistentMap[K, V]
+	edit *hamtEdit
+}
+
+// Builder returns a builder that starts from m. m itself is unaffected.
+func (m *PersistentMap[K, V]) Builder() *PersistentMapBuilder[K, V] {
+	return &PersistentMapBuilder[K, V]{m: *m, edit: &hamtEdit{}}
+}
+
+func (b *PersistentMapBuilder[K, V]) Put(key K, value V) {
+	root, added := b.m.insert(b.m.root, 0, hamtEntry[K, V]{key: key, value: value, hash: b.m.hash(key)}, b.edit)
+
+	b.m.root = root
+	if added {
+		b.m.size++
+	}
+}
+
+func (b *PersistentMapBuilder[K, V]) Delete(key K) bool {
+	root, removed := b.m.remove(b.m.root, 0, b.m.hash(key), key, b.edit)
+
+	b.m.root = root
+	if removed {
+		b.m.size--
+	}
+
+	return removed
+}
+
+func (b *PersistentMapBuilder[K, V]) Lookup(key K) (V, bool) {
+	return b.m.Lookup(key)
+}
+
+func (b *PersistentMapBuilder[K, V]) Len() int {
+	return b.m.size
+}
+
+// Map returns the built map. The builder switches to a new edit token, so
+// it can keep going without changing the map it returned.
+func (b *PersistentMapBuilder[K, V]) Map() *PersistentMap[K, V] {
+	m := b.m.with(b.m.root, b.m.size)
+	b.edit = &hamtEdit{}
+	return m
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// Test that every version keeps its own contents
+func TestPersistentMap_Versions(t *testing.T) {
+	v0 := NewPersistentMap[string, int]()
+	v1 := v0.Put("a", 1)
+	v2 := v1.Put("b", 2)
+	v3 := v2.Put("a", 10)
+	v4 := v3.Delete("b")
+
+	testCases := []struct {
+		name     string
+		m        *PersistentMap[string, int]
+		expected map[string]int
+	}{
+		{"v0", v0, map[string]int{}},
+		{"v1", v1, map[string]int{"a": 1}},
+		{"v2", v2, map[string]int{"a": 1, "b": 2}},
+		{"v3", v3, map[string]int{"a": 10, "b": 2}},
+		{"v4", v4, map[string]int{"a": 10}},
+	}
+
+	for _, tc := range testCases {
+		if tc.m.Len() != len(tc.expected) {
+			t.Errorf("%s: expected Len %d, got %d", tc.name, len(tc.expected), tc.m.Len())
+		}
+
+		for key, value := range tc.expected {
+			if got, ok := tc.m.Lookup(key); !ok || got != value {
+				t.Errorf("%s: expected %s=%d, got (%d, %v)", tc.name, key, value, got, ok)
+			}
+		}
+	}
+
+	if v4.Delete("missing") != v4 {
+		t.Error("Expected deleting a missing key to return the same map")
+	}
+}
+
+// Test that a put copies only the path to its key
+func TestPersistentMap_StructuralSharing(t *testing.T) {
+	b := NewPersistentMap[int, int]().Builder()
+	for i := 0; i < 10000; i++ {
+		b.Put(i, i)
+	}
+	base := b.Map()
+
+	next := base.Put(5, -5)
+
+	// Only the path to key 5 is copied; siblings are shared
+	shared := 0
+	for i := range base.root.entries {
+		if base.root.entries[i].node != nil && base.root.entries[i].node == next.root.entries[i].node {
+			shared++
+		}
+	}
+
+	if shared != len(base.root.entries)-1 {
+		t.Errorf("Expected all but one root child shared, got %d of %d", shared, len(base.root.entries))
+	}
+
+	if value, _ := base.Lookup(5); value != 5 {
+		t.Errorf("Expected old version to keep 5, got %d", value)
+	}
+}
+
+// Test keys whose full hashes collide
+func TestPersistentMap_Collisions(t *testing.T) {
+	m, err := NewPersistentMapWithHasher[int, int](groupedHasher{})
+	if err != nil {
+		t.Fatalf("Failed to create PersistentMap: %v", err)
+	}
+
+	// Keys 0-99 share one full hash, as do 100-199
+	for i := 0; i < 200; i++ {
+		m = m.Put(i, i)
+	}
+
+	for i := 0; i < 200; i += 2 {
+		m = m.Delete(i)
+	}
+
+	for i := 0; i < 200; i++ {
+		if _, ok := m.Lookup(i); ok != (i%2 == 1) {
+			t.Errorf("Unexpected presence %v for key %d", ok, i)
+		}
+	}
+
+	for i := 1; i < 99; i += 2 {
+		m = m.Delete(i)
+	}
+
+	// The last key of a collision node is pulled back up as a leaf
+	if value, ok := m.Lookup(99); !ok || value != 99 || m.Len() != 51 {
+		t.Errorf("Expected 99 to remain among 51 keys, got (%d, %v) and %d", value, ok, m.Len())
+	}
+
+	if _, err := NewPersistentMapWithHasher[int, int](nil); err == nil {
+		t.Error("Expected error for nil hasher, got nil")
+	}
+}
+
+// Test that a builder never changes the maps it started from or returned
+func TestPersistentMap_Builder(t *testing.T) {
+	base := NewPersistentMap[int, int]().Put(1, 1)
+
+	b := base.Builder()
+	for i := 0; i < 1000; i++ {
+		b.Put(i, i*2)
+	}
+	b.Delete(500)
+
+	built := b.Map()
+
+	// The builder keeps working without touching what it returned
+	b.Put(2000, 1)
+	b.Delete(0)
+
+	if base.Len() != 1 || built.Len() != 999 || b.Len() != 999 {
+		t.Errorf("Unexpected lengths %d, %d, %d", base.Len(), built.Len(), b.Len())
+	}
+
+	if value, _ := base.Lookup(1); value != 1 {
+		t.Errorf("Expected base to keep 1, got %d", value)
+	}
+
+	if !built.Contains(0) || built.Contains(2000) || built.Contains(500) {
+		t.Error("Expected built map to be unaffected by later builder changes")
+	}
+
+	if value, ok := b.Lookup(2000); !ok || value != 1 {
+		t.Errorf("Expected builder to see its own changes, got (%d, %v)", value, ok)
+	}
+}
+
+// Test random operations against the built-in map
+func TestPersistentMap_Random(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	m := NewPersistentMap[int, int]()
+	expected := map[int]int{}
+
+	for i := 0; i < 20000; i++ {
+		key := rng.IntN(2000)
+
+		if rng.IntN(3) == 0 {
+			m = m.Delete(key)
+			delete(expected, key)
+		} else {
+			m = m.Put(key, i)
+			expected[key] = i
+		}
+	}
+
+	if m.Len() != len(expected) {
+		t.Fatalf("Expected Len %d, got %d", len(expected), m.Len())
+	}
+
+	seen := 0
+	for key, value := range m.All() {
+		if expected[key] != value {
+			t.Errorf("Key %d: expected %d, got %d", key, expected[key], value)
+		}
+		seen++
+	}
+
+	if seen != len(expected) {
+		t.Errorf("Expected to iterate %d keys, got %d", len(expected), seen)
+	}
+}
@@ -2,7 +2,12 @@ package main
 
 import (
 	"fmt"
+	"hash/maphash"
+	"runtime"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 func BenchmarkHashMapComparison(b *testing.B) {
@@ -100,6 +105,210 @@ func BenchmarkHashMapComparison(b *testing.B) {
 			}
 		})
 
+		// Benchmark ArenaMap - Put
+		b.Run(bm.name+"/ArenaMap/Put", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				a, _ := NewArenaMap(bm.start)
+				for j := 0; j < bm.size; j++ {
+					a.Put(keys[j], values[j])
+				}
+			}
+		})
+
+		// Benchmark ArenaMap - Get
+		b.Run(bm.name+"/ArenaMap/Get", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			a, _ := NewArenaMap(bm.start)
+			for j := 0; j < bm.size; j++ {
+				a.Put(keys[j], values[j])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bm.size; j++ {
+					a.Get(keys[j])
+				}
+			}
+		})
+
+		// Benchmark SwissMap - Put
+		b.Run(bm.name+"/SwissMap/Put", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s, _ := NewSwissMap[string, string](bm.start)
+				for j := 0; j < bm.size; j++ {
+					s.Put(keys[j], values[j])
+				}
+			}
+		})
+
+		// Benchmark SwissMap - Get
+		b.Run(bm.name+"/SwissMap/Get", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			s, _ := NewSwissMap[string, string](bm.start)
+			for j := 0; j < bm.size; j++ {
+				s.Put(keys[j], values[j])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bm.size; j++ {
+					s.Get(keys[j])
+				}
+			}
+		})
+
+		// Benchmark CuckooMap - Put
+		b.Run(bm.name+"/CuckooMap/Put", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c, _ := NewCuckooMap[string, string](bm.start)
+				for j := 0; j < bm.size; j++ {
+					c.Put(keys[j], values[j])
+				}
+			}
+		})
+
+		// Benchmark CuckooMap - Get
+		b.Run(bm.name+"/CuckooMap/Get", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			c, _ := NewCuckooMap[string, string](bm.start)
+			for j := 0; j < bm.size; j++ {
+				c.Put(keys[j], values[j])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bm.size; j++ {
+					c.Get(keys[j])
+				}
+			}
+		})
+
+		// Benchmark Robin Hood HashMap - Put
+		b.Run(bm.name+"/RobinHood/Put", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				hm, _ := NewHashMap(bm.start, WithStrategy(RobinHood))
+				for j := 0; j < bm.size; j++ {
+					hm.Put(keys[j], values[j])
+				}
+			}
+		})
+
+		// Benchmark Robin Hood HashMap - Get
+		b.Run(bm.name+"/RobinHood/Get", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			values := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				values[i] = fmt.Sprintf("value_%d", i)
+			}
+
+			hm, _ := NewHashMap(bm.start, WithStrategy(RobinHood))
+			for j := 0; j < bm.size; j++ {
+				hm.Put(keys[j], values[j])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bm.size; j++ {
+					hm.Get(keys[j])
+				}
+			}
+		})
+
+		// Benchmark Custom HashMap - GetMiss at a high load factor
+		b.Run(bm.name+"/CustomHashMap/GetMiss", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			missing := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				missing[i] = fmt.Sprintf("missing_%d", i)
+			}
+
+			hm, _ := NewHashMap(bm.start, WithLoadFactor(0.9))
+			for j := 0; j < bm.size; j++ {
+				hm.Put(keys[j], keys[j])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bm.size; j++ {
+					hm.Get(missing[j])
+				}
+			}
+		})
+
+		// Benchmark Robin Hood HashMap - GetMiss at a high load factor
+		b.Run(bm.name+"/RobinHood/GetMiss", func(b *testing.B) {
+			keys := make([]string, bm.size)
+			missing := make([]string, bm.size)
+			for i := 0; i < bm.size; i++ {
+				keys[i] = fmt.Sprintf("key_%d", i)
+				missing[i] = fmt.Sprintf("missing_%d", i)
+			}
+
+			hm, _ := NewHashMap(bm.start, WithStrategy(RobinHood), WithLoadFactor(0.9))
+			for j := 0; j < bm.size; j++ {
+				hm.Put(keys[j], keys[j])
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < bm.size; j++ {
+					hm.Get(missing[j])
+				}
+			}
+		})
+
 		// Benchmark Custom HashMap - PutAndGet
 		b.Run(bm.name+"/CustomHashMap/PutAndGet", func(b *testing.B) {
 			keys := make([]string, bm.size)
@@ -189,3 +398,261 @@ func BenchmarkHashMapComparison(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkHashMapPutLatency reports the p99 and worst single Put while
+// filling a map from a small initial size, which is where a stop-the-world
+// rehash shows up.
+func BenchmarkHashMapPutLatency(b *testing.B) {
+	modes := []struct {
+		name string
+		opts []Option
+	}{
+		{"Rehash", nil},
+		{"Incremental", []Option{WithIncrementalRehash(64)}},
+	}
+
+	sizes := []int{1 << 14, 1 << 17, 1 << 20}
+
+	for _, size := range sizes {
+		keys := make([]string, size)
+		for i := 0; i < size; i++ {
+			keys[i] = fmt.Sprintf("key_%d", i)
+		}
+
+		for _, mode := range modes {
+			b.Run(fmt.Sprintf("Size-%d/%s", size, mode.name), func(b *testing.B) {
+				latencies := make([]time.Duration, 0, size*b.N)
+
+				for i := 0; i < b.N; i++ {
+					hm, _ := NewHashMap(16, mode.opts...)
+					for j := 0; j < size; j++ {
+						start := time.Now()
+						hm.Put(keys[j], keys[j])
+						latencies = append(latencies, time.Since(start))
+					}
+				}
+
+				slices.Sort(latencies)
+				b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns/put")
+				b.ReportMetric(float64(latencies[len(latencies)-1].Nanoseconds()), "max-ns/put")
+			})
+		}
+	}
+}
+
+// identityHasher mimics the naive integer hashing many callers start with,
+// which is what turns sequential or strided IDs into clusters.
+type identityHasher struct{}
+
+func (identityHasher) Hash(seed maphash.Seed, key int) uint64 {
+	return uint64(key)
+}
+
+func (identityHasher) Equal(a, b int) bool {
+	return a == b
+}
+
+// BenchmarkProbing compares probe sequences on clustered key sets and
+// reports the average and longest probe per stored key.
+func BenchmarkProbing(b *testing.B) {
+	const size = 1 << 14
+
+	keySets := []struct {
+		name   string
+		hasher Hasher[int]
+		key    func(i int) int
+	}{
+		{"Sequential", ComparableHasher[int]{}, func(i int) int { return i }},
+		{"Sequential/Identity", identityHasher{}, func(i int) int { return i }},
+		{"Strided/Identity", identityHasher{}, func(i int) int { return i * 1024 }},
+	}
+
+	for _, ks := range keySets {
+		for _, p := range probings {
+			b.Run(ks.name+"/"+p.name, func(b *testing.B) {
+				hm, _ := NewHashMapWithHasher[int, int](16, ks.hasher, WithProbing(p.probing), WithLoadFactor(0.9))
+				for i := 0; i < size; i++ {
+					hm.Put(ks.key(i), i)
+				}
+
+				stats := hm.Stats()
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for j := 0; j < size; j++ {
+						hm.Get(ks.key(j))
+					}
+				}
+
+				b.ReportMetric(stats.AvgProbeLength, "avg-probes")
+				b.ReportMetric(float64(stats.MaxProbeLength), "max-probes")
+			})
+		}
+	}
+}
+
+// BenchmarkConcurrentHashMap compares sharded locking against sync.Map
+// from many goroutines at once.
+func BenchmarkConcurrentHashMap(b *testing.B) {
+	const size = 1 << 14
+
+	keys := make([]string, size)
+	for i := 0; i < size; i++ {
+		keys[i] = fmt.Sprintf("key_%d", i)
+	}
+
+	workloads := []struct {
+		name        string
+		writePerMil int
+	}{
+		{"ReadOnly", 0},
+		{"ReadMostly", 100},
+		{"WriteHeavy", 500},
+	}
+
+	for _, wl := range workloads {
+		b.Run(wl.name+"/ConcurrentHashMap", func(b *testing.B) {
+			c, _ := NewConcurrentHashMap[string, string](64, size)
+			for _, key := range keys {
+				c.Put(key, key)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%size]
+					if i%1000 < wl.writePerMil {
+						c.Put(key, key)
+					} else {
+						c.Get(key)
+					}
+					i += 7
+				}
+			})
+		})
+
+		b.Run(wl.name+"/SyncMap", func(b *testing.B) {
+			var m sync.Map
+			for _, key := range keys {
+				m.Store(key, key)
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%size]
+					if i%1000 < wl.writePerMil {
+						m.Store(key, key)
+					} else {
+						m.Load(key)
+					}
+					i += 7
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkArenaMapGC times a full garbage collection with a large map
+// live on the heap. Keys and values are built inside the fill loop, so the
+// map holds the only reference to them. It reports the stop-the-world
+// pause per GC and the number of live heap objects.
+func BenchmarkArenaMapGC(b *testing.B) {
+	const size = 1 << 20
+
+	fills := []struct {
+		name string
+		fill func() any
+	}{
+		{"HashMap", func() any {
+			hm, _ := NewHashMap(16)
+			for i := 0; i < size; i++ {
+				hm.Put(fmt.Sprintf("key_%d", i), fmt.Sprintf("value_%d", i))
+			}
+			return hm
+		}},
+		{"ArenaMap", func() any {
+			a, _ := NewArenaMap(16)
+			for i := 0; i < size; i++ {
+				a.Put(fmt.Sprintf("key_%d", i), fmt.Sprintf("value_%d", i))
+			}
+			return a
+		}},
+		{"GoMap", func() any {
+			m := make(map[string]string)
+			for i := 0; i < size; i++ {
+				m[fmt.Sprintf("key_%d", i)] = fmt.Sprintf("value_%d", i)
+			}
+			return m
+		}},
+	}
+
+	for _, f := range fills {
+		b.Run(f.name, func(b *testing.B) {
+			m := f.fill()
+			runtime.GC()
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runtime.GC()
+			}
+			b.StopTimer()
+
+			runtime.ReadMemStats(&after)
+			runtime.KeepAlive(m)
+
+			b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(after.NumGC-before.NumGC), "pause-ns/gc")
+			b.ReportMetric(float64(after.HeapObjects), "heap-objects")
+		})
+	}
+}
+
+// BenchmarkPersistentMap compares building a PersistentMap one version at
+// a time with a transient builder that updates its own nodes in place.
+func BenchmarkPersistentMap(b *testing.B) {
+	const size = 1 << 14
+
+	keys := make([]string, size)
+	for i := 0; i < size; i++ {
+		keys[i] = fmt.Sprintf("key_%d", i)
+	}
+
+	b.Run("Put", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m := NewPersistentMap[string, int]()
+			for j, key := range keys {
+				m = m.Put(key, j)
+			}
+		}
+	})
+
+	b.Run("Builder", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			builder := NewPersistentMap[string, int]().Builder()
+			for j, key := range keys {
+				builder.Put(key, j)
+			}
+			builder.Map()
+		}
+	})
+
+	b.Run("Lookup", func(b *testing.B) {
+		builder := NewPersistentMap[string, int]().Builder()
+		for j, key := range keys {
+			builder.Put(key, j)
+		}
+		m := builder.Map()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, key := range keys {
+				m.Lookup(key)
+			}
+		}
+	})
+}
@@ -857,3 +857,213 @@ func TestGet_NonExistentAfterOperations(t *testing.T) {
 		t.Errorf("Expected empty value for non-existent key 'nonexistent', got '%s'", value)
 	}
 }
+
+// ============================================
+// DELETE FUNCTION TESTS
+// ============================================
+
+// Test deleting an existing key
+func TestDelete_ExistingKey(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	err = hm.Put("name", "Alice")
+	if err != nil {
+		t.Fatalf("Failed to put key: %v", err)
+	}
+
+	deleted, err := hm.Delete("name")
+	if err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	if !deleted {
+		t.Error("Expected Delete to report the key as present")
+	}
+
+	if hm.occupied != 0 {
+		t.Errorf("Expected occupied to be 0 after delete, got %d", hm.occupied)
+	}
+
+	value, err := hm.Get("name")
+	if err != nil {
+		t.Errorf("Unexpected error getting deleted key: %v", err)
+	}
+	if value != "" {
+		t.Errorf("Expected empty value for deleted key, got '%s'", value)
+	}
+}
+
+// Test deleting a key that doesn't exist
+func TestDelete_NonExistentKey(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("key1", "value1")
+
+	deleted, err := hm.Delete("key2")
+	if err != nil {
+		t.Errorf("Unexpected error deleting non-existent key: %v", err)
+	}
+	if deleted {
+		t.Error("Expected Delete to report the key as absent")
+	}
+
+	if hm.occupied != 1 {
+		t.Errorf("Expected occupied to remain 1, got %d", hm.occupied)
+	}
+}
+
+// Test deleting with empty key
+func TestDelete_EmptyKey(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	deleted, err := hm.Delete("")
+	if err == nil {
+		t.Error("Expected error when deleting with empty key, got nil")
+	}
+	if deleted {
+		t.Error("Expected Delete to report the empty key as absent")
+	}
+}
+
+// Test that deleting from a cluster keeps the remaining keys reachable
+func TestDelete_KeepsProbeChainsIntact(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	// Fill up to the threshold so clusters are guaranteed to form
+	numKeys := 12
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("key%d", i)
+		err := hm.Put(key, fmt.Sprintf("value%d", i))
+		if err != nil {
+			t.Fatalf("Failed to put key '%s': %v", key, err)
+		}
+	}
+
+	// Delete every other key
+	for i := 0; i < numKeys; i += 2 {
+		key := fmt.Sprintf("key%d", i)
+		deleted, err := hm.Delete(key)
+		if err != nil || !deleted {
+			t.Fatalf("Failed to delete key '%s': deleted=%v err=%v", key, deleted, err)
+		}
+	}
+
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expectedValue := ""
+		if i%2 == 1 {
+			expectedValue = fmt.Sprintf("value%d", i)
+		}
+
+		actualValue, err := hm.Get(key)
+		if err != nil {
+			t.Errorf("Unexpected error getting key '%s': %v", key, err)
+		}
+		if actualValue != expectedValue {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expectedValue, actualValue)
+		}
+	}
+
+	// Every remaining entry must be reachable from its home slot without gaps
+	for i, entry := range hm.list {
+		if entry == nil {
+			continue
+		}
+
+		for j := entry.hash & (hm.size - 1); j != uint64(i); j = (j + 1) & (hm.size - 1) {
+			if hm.list[j] == nil {
+				t.Errorf("Key '%s' at slot %d is cut off from its home by empty slot %d", entry.key, i, j)
+				break
+			}
+		}
+	}
+}
+
+// Test deleting then re-inserting the same key
+func TestDelete_ReinsertAfterDelete(t *testing.T) {
+	hm, err := NewHashMap(64)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("status", "pending")
+	hm.Delete("status")
+
+	err = hm.Put("status", "completed")
+	if err != nil {
+		t.Fatalf("Failed to re-insert key: %v", err)
+	}
+
+	if hm.occupied != 1 {
+		t.Errorf("Expected occupied to be 1 after re-insert, got %d", hm.occupied)
+	}
+
+	value, err := hm.Get("status")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if value != "completed" {
+		t.Errorf("Expected 'completed', got '%s'", value)
+	}
+}
+
+// Test interleaving puts and deletes across several rehashes
+func TestDelete_InterleavedWithRehash(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	expected := map[string]string{}
+
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		value := fmt.Sprintf("value%d", i)
+		err := hm.Put(key, value)
+		if err != nil {
+			t.Fatalf("Failed to put key '%s': %v", key, err)
+		}
+		expected[key] = value
+
+		// Delete an older key every third insertion
+		if i%3 == 0 {
+			victim := fmt.Sprintf("key%d", i/2)
+			_, inMap := expected[victim]
+
+			deleted, err := hm.Delete(victim)
+			if err != nil {
+				t.Fatalf("Failed to delete key '%s': %v", victim, err)
+			}
+			if deleted != inMap {
+				t.Errorf("Delete('%s') returned %v, expected %v", victim, deleted, inMap)
+			}
+			delete(expected, victim)
+		}
+	}
+
+	if hm.occupied != uint64(len(expected)) {
+		t.Errorf("Expected occupied to be %d, got %d", len(expected), hm.occupied)
+	}
+
+	for i := 0; i < 500; i++ {
+		key := fmt.Sprintf("key%d", i)
+		actualValue, err := hm.Get(key)
+		if err != nil {
+			t.Errorf("Unexpected error getting key '%s': %v", key, err)
+		}
+		if actualValue != expected[key] {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expected[key], actualValue)
+		}
+	}
+}
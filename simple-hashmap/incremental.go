@@ -0,0 +1,113 @@
+package main
+
+// Incremental rehashing follows the Redis dict design: while a migration is
+// running the map keeps the previous table in h.old and moves a bounded
+// number of its slots into h.list on every operation. New keys only ever
+// go into h.list. Migrated or deleted slots of h.old are replaced by the
+// h.tomb sentinel, so probe chains in the old table stay intact until it is
+// dropped.
+
+func (h *HashMap[K, V]) migrating() bool {
+	return h.old != nil
+}
+
+func (h *HashMap[K, V]) startMigration(newSize uint64) {
+	h.finishMigration()
+
+	h.old = h.list
+	h.oldLive = h.occupied
+	h.rehashes++
+	h.rehashIdx = 0
+	h.size = newSize
+	h.list = make([]*Entry[K, V], h.size)
+	h.maxLoad = h.maxLoadFor(h.size)
+	h.tombstones = 0
+	h.modCount++
+
+	// Only inserts bring the next rehash closer, so moving step slots per
+	// operation finishes within the inserts the new table has room for.
+	// Otherwise the next rehash would have to finish the rest in one go.
+	headroom := max(h.maxLoad-h.occupied, 1)
+	h.step = max(h.incremental, (uint64(len(h.old))+headroom-1)/headroom)
+
+	h.notify(Event[K, V]{Kind: EventRehash, Capacity: h.size})
+}
+
+// migrateStep moves the slots of the old table due for one operation.
+func (h *HashMap[K, V]) migrateStep() {
+	if !h.migrating() {
+		return
+	}
+
+	h.migrate(h.step)
+}
+
+func (h *HashMap[K, V]) finishMigration() {
+	if !h.migrating() {
+		return
+	}
+
+	h.migrate(uint64(len(h.old)))
+}
+
+func (h *HashMap[K, V]) migrate(slots uint64) {
+	end := min(h.rehashIdx+slots, uint64(len(h.old)))
+
+	for ; h.rehashIdx < end; h.rehashIdx++ {
+		entry := h.old[h.rehashIdx]
+
+		if entry == nil || entry == h.tomb {
+			continue
+		}
+
+		h.insertNoRehash(entry)
+		h.old[h.rehashIdx] = h.tomb
+		h.oldLive--
+	}
+
+	if h.oldLive == 0 || h.rehashIdx == uint64(len(h.old)) {
+		h.old = nil
+	}
+}
+
+// findOld looks key up in the old table, stepping over tombstones.
+func (h *HashMap[K, V]) findOld(key K, hash uint64) (uint64, bool) {
+	if !h.migrating() {
+		return 0, false
+	}
+
+	seq := newProbeSeq(h.probing, hash, uint64(len(h.old)))
+
+	for range uint64(len(h.old)) {
+		i := seq.pos
+		entry := h.old[i]
+		seq.next()
+
+		if entry == nil {
+			return 0, false
+		}
+
+		if entry != h.tomb && entry.hash == hash && h.hasher.Equal(entry.key, key) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// pullForward moves key from the old table into the new one, so that write
+// paths only ever have to deal with h.list.
+func (h *HashMap[K, V]) pullForward(key K, hash uint64) {
+	j, found := h.findOld(key, hash)
+	if !found {
+		return
+	}
+
+	h.insertNoRehash(h.old[j])
+	h.old[j] = h.tomb
+	h.oldLive--
+
+	if h.oldLive == 0 {
+		h.old = nil
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test that a zero migration step is rejected
+func TestIncrementalRehash_InvalidStep(t *testing.T) {
+	hm, err := NewHashMap(64, WithIncrementalRehash(0))
+	if err == nil {
+		t.Error("Expected error for zero rehash step, got nil")
+	}
+	if hm != nil {
+		t.Error("Expected nil HashMap for invalid option")
+	}
+}
+
+// Test that crossing the threshold starts a migration instead of a full rehash
+func TestIncrementalRehash_MigratesGradually(t *testing.T) {
+	hm, err := NewHashMap(64, WithIncrementalRehash(4))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 49; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	if !hm.migrating() {
+		t.Fatal("Expected a migration to be running after crossing the threshold")
+	}
+	if hm.size != 128 {
+		t.Errorf("Expected new table of size 128, got %d", hm.size)
+	}
+
+	// Lookups must see keys in both tables while migrating
+	for i := 0; i < 49; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expectedValue := fmt.Sprintf("value%d", i)
+		if value, _ := hm.Get(key); value != expectedValue {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expectedValue, value)
+		}
+	}
+
+	// 49 Gets at 4 slots each cover the 64 old slots
+	if hm.migrating() {
+		t.Errorf("Expected migration to have finished, %d entries left", hm.oldLive)
+	}
+	if hm.occupied != 49 {
+		t.Errorf("Expected occupied to be 49, got %d", hm.occupied)
+	}
+}
+
+// Test updates and deletes of keys still living in the old table
+func TestIncrementalRehash_WritesDuringMigration(t *testing.T) {
+	hm, err := NewHashMap(64, WithIncrementalRehash(1))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 49; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	if !hm.migrating() {
+		t.Fatal("Expected a migration to be running")
+	}
+
+	hm.Put("key10", "updated")
+
+	deleted, _ := hm.Delete("key20")
+	if !deleted {
+		t.Error("Expected key20 to be deleted from the old table")
+	}
+
+	if hm.occupied != 48 {
+		t.Errorf("Expected occupied to be 48, got %d", hm.occupied)
+	}
+
+	for i := 0; i < 49; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expectedValue := fmt.Sprintf("value%d", i)
+
+		switch i {
+		case 10:
+			expectedValue = "updated"
+		case 20:
+			expectedValue = ""
+		}
+
+		if value, _ := hm.Get(key); value != expectedValue {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expectedValue, value)
+		}
+	}
+}
+
+// Test a long run of mixed operations against a builtin map
+func TestIncrementalRehash_MatchesBuiltinMap(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](16, WithIncrementalRehash(2))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	expected := map[int]int{}
+
+	for i := 0; i < 5000; i++ {
+		key := (i * 7919) % 3000
+		switch i % 5 {
+		case 0:
+			hm.Delete(key)
+			delete(expected, key)
+		case 1:
+			value, ok := hm.Lookup(key)
+			expectedValue, expectedOk := expected[key]
+			if ok != expectedOk || value != expectedValue {
+				t.Fatalf("Step %d key %d: expected (%d, %v), got (%d, %v)", i, key, expectedValue, expectedOk, value, ok)
+			}
+		default:
+			hm.Put(key, i)
+			expected[key] = i
+		}
+	}
+
+	if hm.occupied != uint64(len(expected)) {
+		t.Errorf("Expected occupied to be %d, got %d", len(expected), hm.occupied)
+	}
+
+	count := 0
+	for key, value := range hm.All() {
+		count++
+		if expected[key] != value {
+			t.Errorf("Key %d: expected %d, got %d", key, expected[key], value)
+		}
+	}
+
+	if count != len(expected) {
+		t.Errorf("Expected iteration over %d entries, got %d", len(expected), count)
+	}
+}
+
+// Test that even a step of 1 finishes each migration before the next one
+// starts, so no Put has to move the rest of the old table at once
+func TestIncrementalRehash_FinishesBeforeNextGrowth(t *testing.T) {
+	hm, err := NewHashMap(8, WithIncrementalRehash(1))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		wasMigrating, size := hm.migrating(), hm.size
+
+		hm.Put(fmt.Sprintf("key%d", i), "value")
+
+		if hm.size != size && wasMigrating {
+			t.Fatalf("Growth to %d at insert %d had to finish the previous migration", hm.size, i)
+		}
+	}
+}
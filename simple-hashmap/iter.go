@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"iter"
+)
+
+// ErrConcurrentModification is the panic value raised when the map gains or
+// loses keys, or is rehashed, while an iterator is running.
+var ErrConcurrentModification = errors.New("HashMap modified during iteration")
+
+// All iterates over every key/value pair in slot order. Updating the value
+// of an existing key is allowed; any other change panics with
+// ErrConcurrentModification on the next step.
+func (h *HashMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		// A full pass costs as much as the rest of the migration, so finish
+		// it and walk a single table.
+		h.finishMigration()
+
+		list := h.list
+		modCount := h.modCount
+
+		for _, entry := range list {
+			if entry == nil || entry == h.tomb || h.expired(entry) {
+				continue
+			}
+
+			if !yield(entry.key, entry.value) {
+				return
+			}
+
+			if h.modCount != modCount {
+				panic(ErrConcurrentModification)
+			}
+		}
+	}
+}
+
+func (h *HashMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range h.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+func (h *HashMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range h.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// entries visits the live, unexpired entries of both tables without
+// migrating or checking for modification, for callers that only read.
+func (h *HashMap[K, V]) entries() iter.Seq[*Entry[K, V]] {
+	return func(yield func(*Entry[K, V]) bool) {
+		h.entriesAt(h.now())(yield)
+	}
+}
+
+// entriesAt is entries at a fixed time, so that several passes agree on
+// which entries have expired.
+func (h *HashMap[K, V]) entriesAt(now int64) iter.Seq[*Entry[K, V]] {
+	return func(yield func(*Entry[K, V]) bool) {
+		for _, list := range [][]*Entry[K, V]{h.list, h.old} {
+			for _, entry := range list {
+				if entry == nil || entry == h.tomb || entry.expires != 0 && now >= entry.expires {
+					continue
+				}
+
+				if !yield(entry) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test that All visits every entry exactly once
+func TestAll_VisitsEveryEntry(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	expected := map[string]string{}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		value := fmt.Sprintf("value%d", i)
+		hm.Put(key, value)
+		expected[key] = value
+	}
+
+	seen := map[string]string{}
+	for key, value := range hm.All() {
+		if _, dup := seen[key]; dup {
+			t.Errorf("Key '%s' visited twice", key)
+		}
+		seen[key] = value
+	}
+
+	if len(seen) != len(expected) {
+		t.Errorf("Expected %d entries, got %d", len(expected), len(seen))
+	}
+	for key, value := range expected {
+		if seen[key] != value {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, value, seen[key])
+		}
+	}
+}
+
+// Test that Keys and Values cover every entry
+func TestKeysAndValues(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 1; i <= 10; i++ {
+		hm.Put(i, i*10)
+	}
+
+	keySum, valueSum := 0, 0
+	for key := range hm.Keys() {
+		keySum += key
+	}
+	for value := range hm.Values() {
+		valueSum += value
+	}
+
+	if keySum != 55 {
+		t.Errorf("Expected key sum 55, got %d", keySum)
+	}
+	if valueSum != 550 {
+		t.Errorf("Expected value sum 550, got %d", valueSum)
+	}
+}
+
+// Test that breaking out of All stops the iteration
+func TestAll_EarlyBreak(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		hm.Put(i, i)
+	}
+
+	count := 0
+	for range hm.All() {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+
+	if count != 3 {
+		t.Errorf("Expected to stop after 3 entries, got %d", count)
+	}
+}
+
+// Test that values can be updated during iteration, also when the table
+// is at its load threshold
+func TestAll_UpdateDuringIterationAllowed(t *testing.T) {
+	// 16 slots grow at 12 entries
+	for _, n := range []int{10, 12} {
+		hm, err := NewHashMapOf[int, int](16)
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		for i := 0; i < n; i++ {
+			hm.Put(i, i)
+		}
+
+		for key, value := range hm.All() {
+			hm.Put(key, value*2)
+		}
+
+		if hm.size != 16 {
+			t.Errorf("%d entries: expected updates not to grow the table, size is %d", n, hm.size)
+		}
+
+		for i := 0; i < n; i++ {
+			if value, _ := hm.Get(i); value != i*2 {
+				t.Errorf("%d entries: key %d: expected %d, got %d", n, i, i*2, value)
+			}
+		}
+	}
+}
+
+func expectConcurrentModification(t *testing.T, fn func()) {
+	t.Helper()
+
+	defer func() {
+		if r := recover(); r != ErrConcurrentModification {
+			t.Errorf("Expected panic with ErrConcurrentModification, got %v", r)
+		}
+	}()
+
+	fn()
+}
+
+// Test that inserts, deletes and rehashes during iteration panic
+func TestAll_FailFast(t *testing.T) {
+	t.Run("insert", func(t *testing.T) {
+		hm, err := NewHashMapOf[int, int](64)
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		for i := 0; i < 10; i++ {
+			hm.Put(i, i)
+		}
+
+		expectConcurrentModification(t, func() {
+			for key := range hm.Keys() {
+				hm.Put(key+100, key)
+			}
+		})
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		hm, err := NewHashMapOf[int, int](64)
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		for i := 0; i < 10; i++ {
+			hm.Put(i, i)
+		}
+
+		expectConcurrentModification(t, func() {
+			for key := range hm.Keys() {
+				hm.Delete(key)
+			}
+		})
+	})
+
+	t.Run("rehash", func(t *testing.T) {
+		hm, err := NewHashMapOf[int, int](16)
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		for i := 0; i < 12; i++ {
+			hm.Put(i, i)
+		}
+
+		expectConcurrentModification(t, func() {
+			for range hm.Values() {
+				hm.rehash()
+			}
+		})
+	})
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"strconv"
+)
+
+// LinkedHashMap is a HashMap that remembers an order for its keys, like
+// Java's LinkedHashMap. Every entry is also threaded on a doubly linked
+// list, so Put, Get and Delete stay O(1) while iteration and JSON follow
+// the list from front to back.
+type LinkedHashMap[K comparable, V any] struct {
+	m        *HashMap[K, *linkedNode[K, V]]
+	root     linkedNode[K, V] // sentinel, root.next is the front
+	order    LinkedOrder
+	modCount uint64
+}
+
+// LinkedOrder selects how a LinkedHashMap orders its keys.
+type LinkedOrder int
+
+const (
+	// InsertionOrder keeps keys in the order they were first added.
+	// Replacing a value does not move its key.
+	InsertionOrder LinkedOrder = iota
+	// AccessOrder moves a key to the back whenever it is put or read, so
+	// the front is the least recently used key.
+	AccessOrder
+)
+
+type linkedNode[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *linkedNode[K, V]
+}
+
+func NewLinkedHashMap[K comparable, V any](initialSize uint64, order LinkedOrder, opts ...Option) (*LinkedHashMap[K, V], error) {
+	if order != InsertionOrder && order != AccessOrder {
+		return nil, errors.New("Invalid order.")
+	}
+
+	m, err := NewHashMapOf[K, *linkedNode[K, V]](initialSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &LinkedHashMap[K, V]{m: m, order: order}
+	l.root.prev = &l.root
+	l.root.next = &l.root
+	return l, nil
+}
+
+func (l *LinkedHashMap[K, V]) unlink(n *linkedNode[K, V]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+}
+
+func (l *LinkedHashMap[K, V]) insertAfter(n, at *linkedNode[K, V]) {
+	n.prev = at
+	n.next = at.next
+	at.next.prev = n
+	at.next = n
+	l.modCount++
+}
+
+func (l *LinkedHashMap[K, V]) Put(key K, value V) error {
+	if n, ok := l.m.Lookup(key); ok {
+		n.value = value
+
+		if l.order == AccessOrder {
+			l.unlink(n)
+			l.insertAfter(n, l.root.prev)
+		}
+
+		return nil
+	}
+
+	n := &linkedNode[K, V]{key: key, value: value}
+	if err := l.m.Put(key, n); err != nil {
+		return err
+	}
+
+	l.insertAfter(n, l.root.prev)
+	return nil
+}
+
+func (l *LinkedHashMap[K, V]) Get(key K) (V, error) {
+	var zero V
+
+	if err := l.m.checkKey(key); err != nil {
+		return zero, err
+	}
+
+	value, _ := l.Lookup(key)
+	return value, nil
+}
+
+// Lookup returns the value for key. In AccessOrder it also moves key to
+// the back.
+func (l *LinkedHashMap[K, V]) Lookup(key K) (V, bool) {
+	n, ok := l.m.Lookup(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	if l.order == AccessOrder {
+		l.unlink(n)
+		l.insertAfter(n, l.root.prev)
+	}
+
+	return n.value, true
+}
+
+func (l *LinkedHashMap[K, V]) Contains(key K) bool {
+	_, ok := l.m.peek(key)
+	return ok
+}
+
+func (l *LinkedHashMap[K, V]) Delete(key K) (bool, error) {
+	n, ok := l.m.Lookup(key)
+	if !ok {
+		return false, l.m.checkKey(key)
+	}
+
+	if _, err := l.m.Delete(key); err != nil {
+		return false, err
+	}
+
+	l.unlink(n)
+	l.modCount++
+	return true, nil
+}
+
+func (l *LinkedHashMap[K, V]) Len() int {
+	return l.m.Len()
+}
+
+// MoveToFront moves key to the front and reports whether it was present.
+func (l *LinkedHashMap[K, V]) MoveToFront(key K) bool {
+	n, ok := l.m.Lookup(key)
+	if !ok {
+		return false
+	}
+
+	l.unlink(n)
+	l.insertAfter(n, &l.root)
+	return true
+}
+
+// MoveToBack moves key to the back and reports whether it was present.
+func (l *LinkedHashMap[K, V]) MoveToBack(key K) bool {
+	n, ok := l.m.Lookup(key)
+	if !ok {
+		return false
+	}
+
+	l.unlink(n)
+	l.insertAfter(n, l.root.prev)
+	return true
+}
+
+// First returns the entry at the front without counting as an access.
+func (l *LinkedHashMap[K, V]) First() (K, V, bool) {
+	return l.end(l.root.next)
+}
+
+// Last returns the entry at the back without counting as an access.
+func (l *LinkedHashMap[K, V]) Last() (K, V, bool) {
+	return l.end(l.root.prev)
+}
+
+func (l *LinkedHashMap[K, V]) end(n *linkedNode[K, V]) (K, V, bool) {
+	if n == &l.root {
+		var zeroKey K
+		var zeroValue V
+		return zeroKey, zeroValue, false
+	}
+
+	return n.key, n.value, true
+}
+
+// All iterates from front to back. Updating the value of an existing key
+// with Put is allowed in InsertionOrder; adding, deleting or moving keys,
+// including reads in AccessOrder, panics with ErrConcurrentModification
+// on the next step.
+func (l *LinkedHashMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		modCount := l.modCount
+
+		for n := l.root.next; n != &l.root; n = n.next {
+			if !yield(n.key, n.value) {
+				return
+			}
+
+			if l.modCount != modCount {
+				panic(ErrConcurrentModification)
+			}
+		}
+	}
+}
+
+// Backward iterates from back to front, under the same rules as All.
+func (l *LinkedHashMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		modCount := l.modCount
+
+		for n := l.root.prev; n != &l.root; n = n.prev {
+			if !yield(n.key, n.value) {
+				return
+			}
+
+			if l.modCount != modCount {
+				panic(ErrConcurrentModification)
+			}
+		}
+	}
+}
+
+func (l *LinkedHashMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range l.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON writes a JSON object with the keys in list order. Keys are
+// encoded like encoding/json encodes map keys: strings as is, integers in
+// decimal, and other types through encoding.TextMarshaler.
+func (l *LinkedHashMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for key, value := range l.All() {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		name, err := jsonKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedKey, _ := json.Marshal(name)
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedValue)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON adds the members of a JSON object in document order. A key
+// that appears twice keeps its first position and its last value.
+func (l *LinkedHashMap[K, V]) UnmarshalJSON(data []byte) error {
+	if l.m == nil {
+		return errors.New("LinkedHashMap must be created with NewLinkedHashMap.")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return errors.New("LinkedHashMap: expected a JSON object")
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		var key K
+		if err := parseJSONKey(tok.(string), &key); err != nil {
+			return err
+		}
+
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+
+		if err := l.Put(key, value); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+func jsonKey(key any) (string, error) {
+	if tm, ok := key.(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+
+	v := reflect.ValueOf(key)
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+
+	return "", fmt.Errorf("unsupported JSON key type %T", key)
+}
+
+func parseJSONKey(name string, dst any) error {
+	if tu, ok := dst.(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(name))
+	}
+
+	v := reflect.ValueOf(dst).Elem()
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(name)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(name, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(name, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported JSON key type %s", v.Type())
+	}
+
+	return nil
+}
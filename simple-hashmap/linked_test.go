@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test that iteration follows insertion order through rehashes
+func TestLinkedHashMap_InsertionOrder(t *testing.T) {
+	l, err := NewLinkedHashMap[string, int](4, InsertionOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	// Enough keys to force several rehashes of the index
+	var expected []string
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", 99-i)
+		l.Put(key, i)
+		expected = append(expected, key)
+	}
+
+	l.Put("key50", -1)
+	l.Get("key99")
+	l.Delete("key0")
+	expected = slices.DeleteFunc(expected, func(key string) bool { return key == "key0" })
+
+	if keys := slices.Collect(l.Keys()); !slices.Equal(keys, expected) {
+		t.Errorf("Expected keys in insertion order, got %v", keys)
+	}
+
+	if value, _ := l.Get("key50"); value != -1 {
+		t.Errorf("Expected updated value -1, got %d", value)
+	}
+
+	if key, _, _ := l.First(); key != "key99" {
+		t.Errorf("Expected first key key99, got %s", key)
+	}
+
+	if key, _, _ := l.Last(); key != "key1" {
+		t.Errorf("Expected last key key1, got %s", key)
+	}
+}
+
+// Test that reads and puts move keys to the back in AccessOrder
+func TestLinkedHashMap_AccessOrder(t *testing.T) {
+	l, err := NewLinkedHashMap[string, int](4, AccessOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		l.Put(key, 0)
+	}
+
+	l.Get("a")
+	l.Put("b", 1)
+	l.Contains("c") // does not count as an access
+
+	if keys := slices.Collect(l.Keys()); !slices.Equal(keys, []string{"c", "d", "a", "b"}) {
+		t.Errorf("Expected [c d a b], got %v", keys)
+	}
+
+	var backward []string
+	for key := range l.Backward() {
+		backward = append(backward, key)
+	}
+
+	if !slices.Equal(backward, []string{"b", "a", "d", "c"}) {
+		t.Errorf("Expected [b a d c], got %v", backward)
+	}
+}
+
+// Test MoveToFront, MoveToBack, First and Last
+func TestLinkedHashMap_Move(t *testing.T) {
+	l, err := NewLinkedHashMap[string, int](4, InsertionOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.Put(key, 0)
+	}
+
+	l.MoveToFront("c")
+	l.MoveToBack("a")
+
+	if keys := slices.Collect(l.Keys()); !slices.Equal(keys, []string{"c", "b", "a"}) {
+		t.Errorf("Expected [c b a], got %v", keys)
+	}
+
+	if l.MoveToFront("missing") || l.MoveToBack("missing") {
+		t.Error("Expected moving a missing key to report false")
+	}
+
+	l.Delete("a")
+	l.Delete("b")
+	l.Delete("c")
+
+	if _, _, ok := l.First(); ok {
+		t.Error("Expected no first entry in an empty map")
+	}
+
+	if _, _, ok := l.Last(); ok {
+		t.Error("Expected no last entry in an empty map")
+	}
+}
+
+// Test that an access during iteration panics in AccessOrder
+func TestLinkedHashMap_ConcurrentModification(t *testing.T) {
+	l, err := NewLinkedHashMap[string, int](4, AccessOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	l.Put("a", 1)
+	l.Put("b", 2)
+
+	defer func() {
+		if r := recover(); r != ErrConcurrentModification {
+			t.Errorf("Expected ErrConcurrentModification panic, got %v", r)
+		}
+	}()
+
+	for key := range l.Keys() {
+		l.Get(key)
+	}
+}
+
+// Test that JSON keeps list order in both directions
+func TestLinkedHashMap_JSON(t *testing.T) {
+	l, err := NewLinkedHashMap[string, int](4, InsertionOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	l.Put("zebra", 1)
+	l.Put("apple", 2)
+	l.Put("mango", 3)
+
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if string(data) != `{"zebra":1,"apple":2,"mango":3}` {
+		t.Errorf("Expected keys in insertion order, got %s", data)
+	}
+
+	restored, err := NewLinkedHashMap[string, int](4, InsertionOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"b": 1, "a": 2, "b": 3}`), restored); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if keys := slices.Collect(restored.Keys()); !slices.Equal(keys, []string{"b", "a"}) {
+		t.Errorf("Expected [b a], got %v", keys)
+	}
+
+	if value, _ := restored.Get("b"); value != 3 {
+		t.Errorf("Expected last value 3, got %d", value)
+	}
+
+	ints, err := NewLinkedHashMap[int, []string](4, InsertionOrder)
+	if err != nil {
+		t.Fatalf("Failed to create LinkedHashMap: %v", err)
+	}
+
+	ints.Put(10, []string{"x"})
+	ints.Put(-2, nil)
+
+	if data, _ := json.Marshal(ints); string(data) != `{"10":["x"],"-2":null}` {
+		t.Errorf("Unexpected JSON for int keys: %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"x": 1}`), ints); err == nil {
+		t.Error("Expected error for a non-numeric int key, got nil")
+	}
+
+	if err := json.Unmarshal([]byte(`[1]`), restored); err == nil {
+		t.Error("Expected error for a JSON array, got nil")
+	}
+}
@@ -5,123 +5,728 @@ import (
 	"hash/maphash"
 )
 
-type Entry struct {
-	key   string
-	value string
+// Hasher hashes and compares keys of type K. The seed is owned by the map,
+// so implementations must derive the hash from it to stay flooding-safe.
+// Hashers that also implement SeededHasher can be used with WithSeed.
+type Hasher[K any] interface {
+	Hash(seed maphash.Seed, key K) uint64
+	Equal(a, b K) bool
 }
 
-type HashMap struct {
-	list       []*Entry
-	size       uint64
-	occupied   uint64
-	maxLoad    uint64
-	loadFactor float64
-	seed       maphash.Seed
+// ComparableHasher is the default Hasher, backed by maphash.Comparable.
+type ComparableHasher[K comparable] struct{}
+
+func (ComparableHasher[K]) Hash(seed maphash.Seed, key K) uint64 {
+	return maphash.Comparable(seed, key)
+}
+
+func (ComparableHasher[K]) Equal(a, b K) bool {
+	return a == b
+}
+
+// StringHasher is the Hasher used for string keys.
+type StringHasher struct{}
+
+func (StringHasher) Hash(seed maphash.Seed, key string) uint64 {
+	return maphash.String(seed, key)
+}
+
+func (StringHasher) Equal(a, b string) bool {
+	return a == b
+}
+
+func defaultHasher[K comparable]() Hasher[K] {
+	if h, ok := any(StringHasher{}).(Hasher[K]); ok {
+		return h
+	}
+
+	return ComparableHasher[K]{}
+}
+
+type Entry[K comparable, V any] struct {
+	key     K
+	value   V
+	hash    uint64
+	dist    uint64 // probe distance from the home slot
+	expires int64  // unix nanoseconds, 0 for entries without a TTL
 }
 
-func NewHashMap(initialSize uint64) (*HashMap, error) {
+type HashMap[K comparable, V any] struct {
+	list         []*Entry[K, V]
+	size         uint64
+	occupied     uint64
+	maxLoad      uint64
+	loadFactor   float64
+	growthFactor uint64
+	minCapacity  uint64
+	lowWatermark float64
+	strategy     Strategy
+	probing      Probing
+	tombstones   uint64
+	seed         maphash.Seed
+	hasher       Hasher[K]
+	fixed        SeededHasher[K] // set by WithSeed, replaces seed
+	fixedSeed    uint64
+	validate     func(K) error
+	modCount     uint64
+	rehashes     uint64
+	clock        Clock
+	watch        *watchers[K, V] // subscribers, see watch.go
+
+	// Incremental rehashing state, see incremental.go.
+	incremental uint64
+	step        uint64 // slots per operation for the running migration
+	old         []*Entry[K, V]
+	oldLive     uint64
+	rehashIdx   uint64
+	tomb        *Entry[K, V]
+}
+
+// StringHashMap is the original string-to-string map.
+type StringHashMap = HashMap[string, string]
+
+func NewHashMap(initialSize uint64, opts ...Option) (*StringHashMap, error) {
+	h, err := NewHashMapWithHasher[string, string](initialSize, StringHasher{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	h.validate = validateStringKey
+	return h, nil
+}
+
+func NewHashMapOf[K comparable, V any](initialSize uint64, opts ...Option) (*HashMap[K, V], error) {
+	return NewHashMapWithHasher[K, V](initialSize, defaultHasher[K](), opts...)
+}
+
+// NewHashMapWithHasher creates a map whose size is initialSize rounded up to
+// a power of two.
+func NewHashMapWithHasher[K comparable, V any](initialSize uint64, hasher Hasher[K], opts ...Option) (*HashMap[K, V], error) {
 	if initialSize < 1 {
 		return nil, errors.New("Invalid size.")
 	}
 
-	return &HashMap{
-		list:     make([]*Entry, initialSize),
-		size:     initialSize,
-		occupied: 0,
-		maxLoad:  initialSize * 3 / 4,
-		seed:     maphash.MakeSeed(),
-	}, nil
+	if hasher == nil {
+		return nil, errors.New("Invalid hasher.")
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	size := nextPowerOfTwo(initialSize)
+
+	if o.minCapacity == 0 {
+		o.minCapacity = size
+	}
+
+	if o.lowWatermark < 0 {
+		o.lowWatermark = o.loadFactor / 4
+	}
+
+	if o.lowWatermark >= o.loadFactor {
+		return nil, errors.New("Low watermark must be below the load factor.")
+	}
+
+	if o.strategy == RobinHood && o.probing != LinearProbe {
+		return nil, errors.New("Robin Hood hashing requires linear probing.")
+	}
+
+	h := &HashMap[K, V]{
+		loadFactor:   o.loadFactor,
+		growthFactor: o.growthFactor,
+		minCapacity:  o.minCapacity,
+		lowWatermark: o.lowWatermark,
+		strategy:     o.strategy,
+		probing:      o.probing,
+		seed:         maphash.MakeSeed(),
+		hasher:       hasher,
+		incremental:  o.incremental,
+		clock:        o.clock,
+		tomb:         &Entry[K, V]{},
+	}
+
+	if o.seeded {
+		fixed, err := seededHasherFor(hasher)
+		if err != nil {
+			return nil, err
+		}
+
+		h.fixed = fixed
+		h.fixedSeed = o.seed
+		h.validate = seededKeyCheck(hasher)
+	}
+
+	h.resize(max(size, o.minCapacity))
+	return h, nil
+}
+
+// emptyLike returns an empty map with h's configuration, a fresh seed unless
+// WithSeed fixed it, and room for n entries. A zero HashMap gets the defaults of NewHashMapOf.
+func (h *HashMap[K, V]) emptyLike(n uint64) *HashMap[K, V] {
+	e := &HashMap[K, V]{
+		loadFactor:   h.loadFactor,
+		growthFactor: h.growthFactor,
+		minCapacity:  h.minCapacity,
+		lowWatermark: h.lowWatermark,
+		strategy:     h.strategy,
+		probing:      h.probing,
+		seed:         maphash.MakeSeed(),
+		hasher:       h.hasher,
+		fixed:        h.fixed,
+		fixedSeed:    h.fixedSeed,
+		validate:     h.validate,
+		incremental:  h.incremental,
+		clock:        h.clock,
+		tomb:         &Entry[K, V]{},
+	}
+
+	if e.hasher == nil {
+		o := defaultOptions()
+		e.loadFactor = o.loadFactor
+		e.growthFactor = o.growthFactor
+		e.minCapacity = 1
+		e.lowWatermark = o.loadFactor / 4
+		e.hasher = defaultHasher[K]()
+	}
+
+	e.resize(e.capacityFor(n))
+	return e
+}
+
+func validateStringKey(key string) error {
+	if len(key) == 0 {
+		return errors.New("Invalid key")
+	}
+
+	return nil
+}
+
+func (h *HashMap[K, V]) checkKey(key K) error {
+	if h.validate == nil {
+		return nil
+	}
+
+	return h.validate(key)
+}
+
+func (h *HashMap[K, V]) hash(key K) uint64 {
+	if h.fixed != nil {
+		return h.fixed.HashSeed(h.fixedSeed, key)
+	}
+
+	return h.hasher.Hash(h.seed, key)
 }
 
-func (h *HashMap) hash(key string) uint64 {
-	return maphash.String(h.seed, key) & (h.size - 1)
+func (h *HashMap[K, V]) maxLoadFor(size uint64) uint64 {
+	return uint64(float64(size) * h.loadFactor)
 }
 
-func (h *HashMap) rehash() {
+// capacityFor returns the smallest table size that holds n entries
+// without growing.
+func (h *HashMap[K, V]) capacityFor(n uint64) uint64 {
+	size := h.minCapacity
+
+	for h.maxLoadFor(size) < n {
+		size <<= 1
+	}
+
+	return size
+}
+
+// rehash grows the table, or rebuilds it at the same size when tombstones
+// rather than live entries filled it up.
+func (h *HashMap[K, V]) rehash() {
+	newSize := h.size * h.growthFactor
+	if h.occupied < h.maxLoad/2 {
+		newSize = h.size
+	}
+
+	if h.incremental > 0 {
+		h.startMigration(newSize)
+		return
+	}
+
+	h.resize(newSize)
+}
+
+// resize rebuilds the table in one go, completing any running migration first.
+func (h *HashMap[K, V]) resize(newSize uint64) {
+	h.finishMigration()
+
 	oldList := h.list
-	h.size <<= 1
-	h.list = make([]*Entry, h.size)
-	h.occupied = 0
-	h.maxLoad = h.size * 3 / 4
+	if oldList != nil {
+		h.rehashes++
+	}
+
+	h.size = newSize
+	h.list = make([]*Entry[K, V], h.size)
+	h.maxLoad = h.maxLoadFor(h.size)
+	h.tombstones = 0
+	h.modCount++
 
 	for _, entry := range oldList {
-		if entry != nil {
+		if entry != nil && entry != h.tomb {
 			h.insertNoRehash(entry)
 		}
 	}
+
+	if oldList != nil {
+		h.notify(Event[K, V]{Kind: EventRehash, Capacity: h.size})
+	}
+}
+
+// Reserve grows the table so that it holds n entries without rehashing.
+func (h *HashMap[K, V]) Reserve(n uint64) {
+	if size := h.capacityFor(n); size > h.size {
+		h.resize(size)
+	}
 }
 
-func (h *HashMap) insertNoRehash(e *Entry) {
-	idx := h.hash(e.key)
+// Shrink resizes the table to the smallest size that fits its entries,
+// but never below the minimum capacity.
+func (h *HashMap[K, V]) Shrink() {
+	if size := h.capacityFor(h.occupied); size < h.size {
+		h.resize(size)
+	}
+}
 
-	for probe := range h.size {
-		i := (idx + probe) & (h.size - 1)
+// Clone returns a copy of the map with the same configuration and seed.
+// The copy shares no entries or subscribers, so either map can change
+// independently.
+func (h *HashMap[K, V]) Clone() *HashMap[K, V] {
+	c := *h
+	c.tomb = &Entry[K, V]{}
+	c.modCount = 0
+	c.watch = nil
+	c.list = h.cloneSlots(h.list, c.tomb)
+	c.old = h.cloneSlots(h.old, c.tomb)
+	return &c
+}
 
-		if h.list[i] == nil {
-			h.list[i] = e
-			h.occupied++
-			return
+// cloneSized is Clone into a table with room for n entries, so a copy that
+// is about to grow is built only once. Cached hashes are reused.
+func (h *HashMap[K, V]) cloneSized(n uint64) *HashMap[K, V] {
+	c := *h
+	c.tomb = &Entry[K, V]{}
+	c.modCount = 0
+	c.watch = nil
+	c.list, c.old = nil, nil
+	c.occupied = 0
+	c.resize(c.capacityFor(max(n, h.occupied)))
+
+	for _, list := range [][]*Entry[K, V]{h.list, h.old} {
+		for _, entry := range list {
+			if entry == nil || entry == h.tomb {
+				continue
+			}
+
+			e := *entry
+			c.insertNoRehash(&e)
+			c.occupied++
 		}
 	}
 
-	panic("rehash insert failed")
+	return &c
 }
 
-func (h *HashMap) Put(key, value string) error {
-	if len(key) == 0 {
-		return errors.New("Invalid key")
+func (h *HashMap[K, V]) cloneSlots(slots []*Entry[K, V], tomb *Entry[K, V]) []*Entry[K, V] {
+	if slots == nil {
+		return nil
 	}
 
-	if h.occupied >= h.maxLoad {
-		h.rehash()
+	clone := make([]*Entry[K, V], len(slots))
+	for i, entry := range slots {
+		switch entry {
+		case nil:
+		case h.tomb:
+			clone[i] = tomb
+		default:
+			e := *entry
+			clone[i] = &e
+		}
+	}
+
+	return clone
+}
+
+// maybeShrink halves the load after deletes drop occupancy below the low
+// watermark, leaving room to grow again before the next rehash.
+func (h *HashMap[K, V]) maybeShrink() {
+	if h.size <= h.minCapacity {
+		return
+	}
+
+	if float64(h.occupied) >= float64(h.size)*h.lowWatermark {
+		return
+	}
+
+	if size := h.capacityFor(h.occupied * 2); size < h.size {
+		h.resize(size)
+	}
+}
+
+// insertNoRehash reuses the cached hash, so growing never calls the Hasher.
+func (h *HashMap[K, V]) insertNoRehash(e *Entry[K, V]) {
+	e.dist = 0
+	h.placeFrom(e, h.probe(e.hash))
+}
+
+// placeFrom stores e at the current slot of seq or further along it. e.dist
+// must already be its distance at that slot. In Robin Hood mode an entry
+// that is further from home than the resident takes its slot, and the
+// resident carries on probing.
+func (h *HashMap[K, V]) placeFrom(e *Entry[K, V], seq probeSeq) {
+	for range h.size {
+		resident := h.list[seq.pos]
+
+		if resident == nil || resident == h.tomb {
+			if resident == h.tomb {
+				h.tombstones--
+			}
+
+			h.list[seq.pos] = e
+			return
+		}
+
+		// Robin Hood requires linear probing, so the resident's sequence
+		// continues from the same slot.
+		if h.strategy == RobinHood && resident.dist < e.dist {
+			h.list[seq.pos], e = e, resident
+		}
+
+		seq.next()
+		e.dist++
 	}
 
-	idx := h.hash(key)
+	panic("rehash insert failed")
+}
+
+// find returns the slot holding key, or the slot where key would be inserted.
+func (h *HashMap[K, V]) find(key K, hash uint64) (uint64, bool) {
+	seq := h.probe(hash)
+	free := h.size
 
 	for probe := range h.size {
-		i := (idx + probe) & (h.size - 1)
+		i := seq.pos
 		entry := h.list[i]
 
+		// Delete keeps probe chains contiguous, so an empty slot ends the search.
 		if entry == nil {
-			h.list[i] = &Entry{key, value}
-			h.occupied++
-			return nil
+			if free != h.size {
+				return free, false
+			}
+
+			return i, false
 		}
 
-		if entry.key == key {
-			entry.value = value
-			return nil
+		switch {
+		case entry == h.tomb:
+			// Only non-linear probing leaves tombstones; reuse the first one.
+			if free == h.size {
+				free = i
+			}
+		case h.strategy == RobinHood && entry.dist < probe:
+			// A Robin Hood table would have placed key before any entry
+			// that is closer to its own home.
+			return i, false
+		case entry.hash == hash && h.hasher.Equal(entry.key, key):
+			return i, true
 		}
+
+		seq.next()
 	}
 
-	panic("HashMap is full")
+	return free, false
 }
 
-func (h *HashMap) Get(key string) (string, error) {
-	if len(key) == 0 {
-		return "", errors.New("Invalid key")
+// findForWrite runs the single probe sequence shared by every write
+// operation and grows the table if the key is new and needs room.
+func (h *HashMap[K, V]) findForWrite(key K) (uint64, uint64, bool, error) {
+	if err := h.checkKey(key); err != nil {
+		return 0, 0, false, err
+	}
+
+	h.migrateStep()
+
+	hash := h.hash(key)
+	h.pullForward(key, hash)
+	i, found := h.find(key, hash)
+
+	// To a write an expired entry is already gone.
+	if found && h.expired(h.list[i]) {
+		h.deleteAt(i)
+		i, found = h.find(key, hash)
+	}
+
+	// Updating an existing key never rehashes, so iterators allow it.
+	if !found && h.occupied+h.tombstones >= h.maxLoad {
+		h.rehash()
+		i, _ = h.find(key, hash)
+	}
+
+	if !found && i == h.size {
+		panic("HashMap is full")
+	}
+
+	return hash, i, found, nil
+}
+
+func (h *HashMap[K, V]) insertAt(i uint64, key K, value V, hash uint64) *Entry[K, V] {
+	e := &Entry[K, V]{key: key, value: value, hash: hash, dist: h.distance(i, hash)}
+
+	seq := h.probe(hash)
+	seq.pos = i
+	h.placeFrom(e, seq)
+	h.occupied++
+	h.modCount++
+	h.notify(Event[K, V]{Kind: EventPut, Key: key, New: value})
+	return e
+}
+
+func (h *HashMap[K, V]) Put(key K, value V) error {
+	hash, i, found, err := h.findForWrite(key)
+	if err != nil {
+		return err
+	}
+
+	// Put replaces the value and any TTL it had.
+	if found {
+		h.setValue(h.list[i], value)
+		h.list[i].expires = 0
+		return nil
+	}
+
+	h.insertAt(i, key, value, hash)
+	return nil
+}
+
+func (h *HashMap[K, V]) Get(key K) (V, error) {
+	var zero V
+
+	if err := h.checkKey(key); err != nil {
+		return zero, err
+	}
+
+	value, _ := h.Lookup(key)
+	return value, nil
+}
+
+// Lookup returns the value stored for key and whether it was present, which
+// tells a missing key apart from one stored with the zero value.
+func (h *HashMap[K, V]) Lookup(key K) (V, bool) {
+	var zero V
+
+	if h.checkKey(key) != nil {
+		return zero, false
 	}
 
-	idx := h.hash(key)
-	entry := h.list[idx]
+	h.migrateStep()
+
+	hash := h.hash(key)
+	entry := h.lookupEntry(key, hash)
 
 	if entry == nil {
-		return "", nil
+		return zero, false
 	}
 
-	if entry.key == key {
-		return entry.value, nil
+	if h.expired(entry) {
+		h.reclaim(key, hash)
+		return zero, false
 	}
 
-	for probe := range h.size {
-		i := (idx + probe) & (h.size - 1)
+	return entry.value, true
+}
 
-		entry = h.list[i]
+// peek looks key up in both tables without migrating or reclaiming
+// anything.
+func (h *HashMap[K, V]) peek(key K) (V, bool) {
+	var zero V
 
-		if entry != nil && entry.key == key {
-			return entry.value, nil
-		}
+	entry := h.lookupEntry(key, h.hash(key))
+	if entry == nil || h.expired(entry) {
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+func (h *HashMap[K, V]) lookupEntry(key K, hash uint64) *Entry[K, V] {
+	if i, found := h.find(key, hash); found {
+		return h.list[i]
+	}
+
+	if j, found := h.findOld(key, hash); found {
+		return h.old[j]
+	}
+
+	return nil
+}
+
+// Len counts expired entries until they are reclaimed, see PurgeExpired.
+func (h *HashMap[K, V]) Len() int {
+	return int(h.occupied)
+}
+
+// GetOrDefault returns the value stored for key, or defaultValue if absent.
+func (h *HashMap[K, V]) GetOrDefault(key K, defaultValue V) V {
+	if value, ok := h.Lookup(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+func (h *HashMap[K, V]) Contains(key K) bool {
+	_, ok := h.Lookup(key)
+	return ok
+}
+
+// LoadOrStore returns the existing value for key if present. Otherwise it
+// stores value and returns it. loaded reports whether the value was present.
+func (h *HashMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool, err error) {
+	hash, i, found, err := h.findForWrite(key)
+	if err != nil {
+		return actual, false, err
+	}
+
+	if found {
+		return h.list[i].value, true, nil
+	}
+
+	h.insertAt(i, key, value, hash)
+	return value, false, nil
+}
+
+// Compute calls fn with the current value of key and whether it exists.
+// If fn returns keep == true the result is stored, otherwise key is removed.
+// Compute returns the value now stored for key.
+func (h *HashMap[K, V]) Compute(key K, fn func(value V, ok bool) (newValue V, keep bool)) (V, error) {
+	var zero V
+
+	hash, i, found, err := h.findForWrite(key)
+	if err != nil {
+		return zero, err
+	}
+
+	var old V
+	if found {
+		old = h.list[i].value
+	}
+
+	value, keep := fn(old, found)
+
+	switch {
+	case keep && found:
+		h.setValue(h.list[i], value)
+	case keep:
+		h.insertAt(i, key, value, hash)
+	case found:
+		h.deleteAt(i)
+		h.maybeShrink()
+		return zero, nil
+	default:
+		return zero, nil
+	}
+
+	return value, nil
+}
+
+// ComputeIfAbsent returns the value for key, storing fn(key) first if absent.
+func (h *HashMap[K, V]) ComputeIfAbsent(key K, fn func(key K) V) (V, error) {
+	var zero V
+
+	hash, i, found, err := h.findForWrite(key)
+	if err != nil {
+		return zero, err
+	}
+
+	if found {
+		return h.list[i].value, nil
+	}
+
+	value := fn(key)
+	h.insertAt(i, key, value, hash)
+	return value, nil
+}
+
+// Merge stores value if key is absent, otherwise fn(old, value), and
+// returns the value now stored for key.
+func (h *HashMap[K, V]) Merge(key K, value V, fn func(old, value V) V) (V, error) {
+	var zero V
+
+	hash, i, found, err := h.findForWrite(key)
+	if err != nil {
+		return zero, err
+	}
+
+	if found {
+		h.setValue(h.list[i], fn(h.list[i].value, value))
+		return h.list[i].value, nil
 	}
 
-	return "", nil
+	h.insertAt(i, key, value, hash)
+	return value, nil
+}
+
+// Delete removes key from the map and reports whether it was present.
+func (h *HashMap[K, V]) Delete(key K) (bool, error) {
+	if err := h.checkKey(key); err != nil {
+		return false, err
+	}
+
+	h.migrateStep()
+
+	hash := h.hash(key)
+	h.pullForward(key, hash)
+	i, found := h.find(key, hash)
+
+	if !found {
+		return false, nil
+	}
+
+	h.deleteAt(i)
+	h.maybeShrink()
+	return true, nil
+}
+
+// deleteAt empties slot i. With linear probing it shifts the rest of the
+// cluster back so that every entry stays reachable from its home slot
+// without tombstones. Other sequences jump over slots, so there a
+// tombstone is left behind instead.
+func (h *HashMap[K, V]) deleteAt(i uint64) {
+	mask := h.size - 1
+	removed := h.list[i]
+	h.occupied--
+	h.modCount++
+	h.notify(Event[K, V]{Kind: EventDelete, Key: removed.key, Old: removed.value, HasOld: true})
+
+	if h.probing != LinearProbe {
+		h.list[i] = h.tomb
+		h.tombstones++
+		return
+	}
+
+	h.list[i] = nil
+
+	for j := (i + 1) & mask; h.list[j] != nil; j = (j + 1) & mask {
+		entry := h.list[j]
+
+		// Robin Hood clusters are sorted by home, so nothing after an entry
+		// sitting in its home slot can move.
+		if h.strategy == RobinHood && entry.dist == 0 {
+			break
+		}
+
+		// The entry may fill the hole only if its home is not in (i, j].
+		if entry.dist >= (j-i)&mask {
+			entry.dist -= (j - i) & mask
+			h.list[i] = entry
+			h.list[j] = nil
+			i = j
+		}
+	}
 }
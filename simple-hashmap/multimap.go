@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"iter"
+	"slices"
+)
+
+// MultiMap maps each key to several values. Keys live in a HashMap, and
+// under SetValues each key's values live in a HashSet, so both levels use
+// the same probing engine. A key disappears once its last value is removed.
+type MultiMap[K, V comparable] struct {
+	m         *HashMap[K, *multiValues[V]]
+	semantics ValueSemantics
+	len       int
+}
+
+// ValueSemantics selects how a MultiMap stores the values of a key.
+type ValueSemantics int
+
+const (
+	// ListValues keeps values in insertion order and allows duplicates.
+	ListValues ValueSemantics = iota
+	// SetValues ignores a value the key already has. Values come back in
+	// no particular order.
+	SetValues
+)
+
+type multiValues[V comparable] struct {
+	list []V
+	set  *HashSet[V]
+}
+
+func (mv *multiValues[V]) count() int {
+	if mv.set != nil {
+		return mv.set.Len()
+	}
+
+	return len(mv.list)
+}
+
+func NewMultiMap[K, V comparable](initialSize uint64, semantics ValueSemantics, opts ...Option) (*MultiMap[K, V], error) {
+	if semantics != ListValues && semantics != SetValues {
+		return nil, errors.New("Invalid value semantics.")
+	}
+
+	m, err := NewHashMapOf[K, *multiValues[V]](initialSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiMap[K, V]{m: m, semantics: semantics}, nil
+}
+
+// Put adds value to key and reports whether it was added, which is always
+// true for ListValues.
+func (mm *MultiMap[K, V]) Put(key K, value V) bool {
+	values, _ := mm.m.ComputeIfAbsent(key, func(K) *multiValues[V] {
+		mv := &multiValues[V]{}
+		if mm.semantics == SetValues {
+			mv.set, _ = NewHashSet[V](4)
+		}
+		return mv
+	})
+
+	if values.set != nil {
+		if !values.set.Add(value) {
+			return false
+		}
+	} else {
+		values.list = append(values.list, value)
+	}
+
+	mm.len++
+	return true
+}
+
+// GetAll returns a copy of the values of key, or nil if it has none.
+func (mm *MultiMap[K, V]) GetAll(key K) []V {
+	values, ok := mm.m.Lookup(key)
+	if !ok {
+		return nil
+	}
+
+	if values.set != nil {
+		return slices.Collect(values.set.All())
+	}
+
+	return slices.Clone(values.list)
+}
+
+// Contains reports whether key has value.
+func (mm *MultiMap[K, V]) Contains(key K, value V) bool {
+	values, ok := mm.m.Lookup(key)
+	if !ok {
+		return false
+	}
+
+	if values.set != nil {
+		return values.set.Contains(value)
+	}
+
+	return slices.Contains(values.list, value)
+}
+
+func (mm *MultiMap[K, V]) ContainsKey(key K) bool {
+	return mm.m.Contains(key)
+}
+
+// RemoveValue removes one occurrence of value from key, the first one under
+// ListValues, and reports whether there was one.
+func (mm *MultiMap[K, V]) RemoveValue(key K, value V) bool {
+	values, ok := mm.m.Lookup(key)
+	if !ok {
+		return false
+	}
+
+	if values.set != nil {
+		if !values.set.Remove(value) {
+			return false
+		}
+	} else {
+		i := slices.Index(values.list, value)
+		if i < 0 {
+			return false
+		}
+
+		values.list = slices.Delete(values.list, i, i+1)
+	}
+
+	mm.len--
+
+	if values.count() == 0 {
+		mm.m.Delete(key)
+	}
+
+	return true
+}
+
+// RemoveAll removes key with all its values and returns how many there were.
+func (mm *MultiMap[K, V]) RemoveAll(key K) int {
+	values, ok := mm.m.Lookup(key)
+	if !ok {
+		return 0
+	}
+
+	mm.m.Delete(key)
+
+	n := values.count()
+	mm.len -= n
+	return n
+}
+
+// Count returns the number of values of key.
+func (mm *MultiMap[K, V]) Count(key K) int {
+	values, ok := mm.m.Lookup(key)
+	if !ok {
+		return 0
+	}
+
+	return values.count()
+}
+
+// Len returns the number of key/value pairs.
+func (mm *MultiMap[K, V]) Len() int {
+	return mm.len
+}
+
+// KeyLen returns the number of distinct keys.
+func (mm *MultiMap[K, V]) KeyLen() int {
+	return mm.m.Len()
+}
+
+func (mm *MultiMap[K, V]) Keys() iter.Seq[K] {
+	return mm.m.Keys()
+}
+
+// All iterates over every key/value pair, the values of a key together.
+// The loop body must not change the map.
+func (mm *MultiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for key, values := range mm.m.All() {
+			if values.set != nil {
+				for value := range values.set.All() {
+					if !yield(key, value) {
+						return
+					}
+				}
+
+				continue
+			}
+
+			for _, value := range values.list {
+				if !yield(key, value) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Test that ListValues keeps duplicates in insertion order
+func TestMultiMap_ListValues(t *testing.T) {
+	mm, err := NewMultiMap[string, int](4, ListValues)
+	if err != nil {
+		t.Fatalf("Failed to create MultiMap: %v", err)
+	}
+
+	for _, value := range []int{3, 1, 3, 2} {
+		if !mm.Put("tag", value) {
+			t.Errorf("Expected list Put to always add, failed for %d", value)
+		}
+	}
+	mm.Put("other", 9)
+
+	if values := mm.GetAll("tag"); !slices.Equal(values, []int{3, 1, 3, 2}) {
+		t.Errorf("Expected [3 1 3 2], got %v", values)
+	}
+
+	if !mm.RemoveValue("tag", 3) || mm.RemoveValue("tag", 7) {
+		t.Error("Unexpected RemoveValue result")
+	}
+
+	if values := mm.GetAll("tag"); !slices.Equal(values, []int{1, 3, 2}) {
+		t.Errorf("Expected first occurrence removed, got %v", values)
+	}
+
+	if mm.Count("tag") != 3 || mm.Len() != 4 || mm.KeyLen() != 2 {
+		t.Errorf("Unexpected counts %d, %d, %d", mm.Count("tag"), mm.Len(), mm.KeyLen())
+	}
+
+	// GetAll returns a copy
+	mm.GetAll("tag")[0] = 100
+	if !mm.Contains("tag", 1) || mm.Contains("tag", 100) {
+		t.Error("Expected GetAll result not to alias the stored values")
+	}
+}
+
+// Test that SetValues ignores duplicate values
+func TestMultiMap_SetValues(t *testing.T) {
+	mm, err := NewMultiMap[string, int](4, SetValues)
+	if err != nil {
+		t.Fatalf("Failed to create MultiMap: %v", err)
+	}
+
+	mm.Put("tag", 1)
+	mm.Put("tag", 2)
+	if mm.Put("tag", 1) {
+		t.Error("Expected set Put to ignore a duplicate")
+	}
+
+	if values := slices.Sorted(slices.Values(mm.GetAll("tag"))); !slices.Equal(values, []int{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", values)
+	}
+
+	mm.RemoveValue("tag", 1)
+	mm.RemoveValue("tag", 2)
+
+	if mm.ContainsKey("tag") || mm.Len() != 0 || mm.GetAll("tag") != nil {
+		t.Error("Expected key to be dropped with its last value")
+	}
+}
+
+// Test RemoveAll and iteration under both value semantics
+func TestMultiMap_RemoveAll(t *testing.T) {
+	for _, semantics := range []ValueSemantics{ListValues, SetValues} {
+		mm, err := NewMultiMap[string, int](4, semantics)
+		if err != nil {
+			t.Fatalf("Failed to create MultiMap: %v", err)
+		}
+
+		for i := 0; i < 100; i++ {
+			mm.Put(fmt.Sprintf("tag%d", i%10), i)
+		}
+
+		if n := mm.RemoveAll("tag3"); n != 10 {
+			t.Errorf("Expected 10 values removed, got %d", n)
+		}
+
+		if mm.RemoveAll("tag3") != 0 || mm.Count("tag3") != 0 {
+			t.Error("Expected tag3 to be gone")
+		}
+
+		pairs := 0
+		for key, value := range mm.All() {
+			if key != fmt.Sprintf("tag%d", value%10) {
+				t.Errorf("Unexpected pair %s=%d", key, value)
+			}
+			pairs++
+		}
+
+		if pairs != 90 || mm.Len() != 90 || mm.KeyLen() != 9 {
+			t.Errorf("Expected 90 pairs over 9 keys, got %d (Len %d) over %d", pairs, mm.Len(), mm.KeyLen())
+		}
+	}
+
+	if _, err := NewMultiMap[string, int](4, ValueSemantics(5)); err == nil {
+		t.Error("Expected error for invalid semantics, got nil")
+	}
+}
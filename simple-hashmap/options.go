@@ -0,0 +1,166 @@
+package main
+
+import "errors"
+
+type options struct {
+	loadFactor   float64
+	growthFactor uint64
+	minCapacity  uint64
+	lowWatermark float64
+	incremental  uint64
+	strategy     Strategy
+	probing      Probing
+	clock        Clock
+	seed         uint64
+	seeded       bool
+}
+
+// Strategy selects how colliding keys are placed in the table.
+type Strategy int
+
+const (
+	// LinearProbing stores a key in the first free slot after its home.
+	LinearProbing Strategy = iota
+	// RobinHood also probes linearly but lets a key displace any entry that
+	// is closer to its own home, which bounds probe lengths and lets a miss
+	// stop early.
+	RobinHood
+)
+
+// Option configures a HashMap at construction time.
+type Option func(*options) error
+
+func defaultOptions() options {
+	return options{
+		loadFactor:   0.75,
+		growthFactor: 2,
+		lowWatermark: -1,
+	}
+}
+
+// WithLoadFactor sets the occupancy, in (0, 1), at which the table grows.
+func WithLoadFactor(loadFactor float64) Option {
+	return func(o *options) error {
+		if loadFactor <= 0 || loadFactor >= 1 {
+			return errors.New("Invalid load factor.")
+		}
+
+		o.loadFactor = loadFactor
+		return nil
+	}
+}
+
+// WithGrowthFactor sets how many times larger the table gets on each grow.
+// It is rounded up to a power of two so that slots can still be masked.
+func WithGrowthFactor(growthFactor uint64) Option {
+	return func(o *options) error {
+		if growthFactor < 2 {
+			return errors.New("Invalid growth factor.")
+		}
+
+		o.growthFactor = nextPowerOfTwo(growthFactor)
+		return nil
+	}
+}
+
+// WithMinCapacity sets the size below which the table never shrinks.
+// By default this is the initial size.
+func WithMinCapacity(minCapacity uint64) Option {
+	return func(o *options) error {
+		if minCapacity < 1 {
+			return errors.New("Invalid minimum capacity.")
+		}
+
+		o.minCapacity = nextPowerOfTwo(minCapacity)
+		return nil
+	}
+}
+
+// WithLowWatermark sets the occupancy below which Delete shrinks the table.
+// Zero disables automatic shrinking; the default is a quarter of the load factor.
+func WithLowWatermark(lowWatermark float64) Option {
+	return func(o *options) error {
+		if lowWatermark < 0 || lowWatermark >= 1 {
+			return errors.New("Invalid low watermark.")
+		}
+
+		o.lowWatermark = lowWatermark
+		return nil
+	}
+}
+
+func WithStrategy(strategy Strategy) Option {
+	return func(o *options) error {
+		if strategy != LinearProbing && strategy != RobinHood {
+			return errors.New("Invalid strategy.")
+		}
+
+		o.strategy = strategy
+		return nil
+	}
+}
+
+// WithIncrementalRehash spreads growing over later operations instead of
+// rebuilding the table inside a single Put. Each Put, Get or Delete then
+// migrates at least step slots from the old table to the new one, and more
+// if needed to finish before the new table fills up.
+func WithIncrementalRehash(step uint64) Option {
+	return func(o *options) error {
+		if step < 1 {
+			return errors.New("Invalid rehash step.")
+		}
+
+		o.incremental = step
+		return nil
+	}
+}
+
+// WithProbing selects the probe sequence. Robin Hood hashing only works
+// with LinearProbe. Non-linear probing leaves tombstones on Delete, which
+// are cleared by the next rehash.
+func WithProbing(probing Probing) Option {
+	return func(o *options) error {
+		if probing != LinearProbe && probing != QuadraticProbe && probing != DoubleHashProbe {
+			return errors.New("Invalid probing.")
+		}
+
+		o.probing = probing
+		return nil
+	}
+}
+
+// WithClock sets the time source for entry TTLs, see PutWithTTL.
+func WithClock(clock Clock) Option {
+	return func(o *options) error {
+		if clock == nil {
+			return errors.New("Invalid clock.")
+		}
+
+		o.clock = clock
+		return nil
+	}
+}
+
+// WithSeed replaces the random per-map maphash seed with a fixed one, so
+// the same keys inserted in the same order always land in the same slots.
+// Use it for golden tests and comparable benchmark runs only: anyone who
+// knows the seed can compute keys that collide and degrade every
+// operation to a linear scan, which the default random seed prevents.
+// The Hasher must implement SeededHasher; the built-in ones do.
+func WithSeed(seed uint64) Option {
+	return func(o *options) error {
+		o.seed = seed
+		o.seeded = true
+		return nil
+	}
+}
+
+func nextPowerOfTwo(n uint64) uint64 {
+	size := uint64(1)
+
+	for size < n {
+		size <<= 1
+	}
+
+	return size
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test that non-power-of-two sizes are rounded up
+func TestNewHashMap_RoundsUpToPowerOfTwo(t *testing.T) {
+	testCases := map[uint64]uint64{1: 1, 3: 4, 5: 8, 100: 128, 1024: 1024, 1025: 2048}
+
+	for initialSize, expectedSize := range testCases {
+		hm, err := NewHashMap(initialSize)
+		if err != nil {
+			t.Fatalf("Unexpected error for size %d: %v", initialSize, err)
+		}
+		if hm.size != expectedSize {
+			t.Errorf("Size %d: expected table of %d, got %d", initialSize, expectedSize, hm.size)
+		}
+	}
+}
+
+// Test that invalid options are rejected with a nil map
+func TestNewHashMap_InvalidOptions(t *testing.T) {
+	testCases := []struct {
+		name string
+		opt  Option
+	}{
+		{"zero load factor", WithLoadFactor(0)},
+		{"full load factor", WithLoadFactor(1)},
+		{"growth factor of one", WithGrowthFactor(1)},
+		{"zero min capacity", WithMinCapacity(0)},
+		{"negative low watermark", WithLowWatermark(-0.1)},
+		{"low watermark above load factor", WithLowWatermark(0.8)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hm, err := NewHashMap(64, tc.opt)
+			if err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if hm != nil {
+				t.Error("Expected nil HashMap for invalid option")
+			}
+		})
+	}
+}
+
+// Test that the table grows once the load factor is exceeded
+func TestWithLoadFactor(t *testing.T) {
+	hm, err := NewHashMap(64, WithLoadFactor(0.5))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 32; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), "value")
+	}
+
+	if hm.size != 64 {
+		t.Errorf("Expected size 64 at the threshold, got %d", hm.size)
+	}
+
+	hm.Put("trigger", "rehash")
+
+	if hm.size != 128 {
+		t.Errorf("Expected size 128 after exceeding 0.5 load, got %d", hm.size)
+	}
+}
+
+// Test that the growth factor is rounded up to a power of two
+func TestWithGrowthFactor(t *testing.T) {
+	hm, err := NewHashMap(16, WithGrowthFactor(3))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 13; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), "value")
+	}
+
+	// A factor of 3 is rounded up to 4
+	if hm.size != 64 {
+		t.Errorf("Expected size 64 after growing by 4, got %d", hm.size)
+	}
+}
+
+// Test that Reserve makes room up front and never shrinks
+func TestReserve(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Reserve(1000)
+	size := hm.size
+
+	if hm.maxLoad < 1000 {
+		t.Errorf("Expected room for 1000 entries, max load is %d", hm.maxLoad)
+	}
+
+	for i := 0; i < 1000; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), "value")
+	}
+
+	if hm.size != size {
+		t.Errorf("Expected no rehash after Reserve, size went from %d to %d", size, hm.size)
+	}
+
+	// Reserving less than the current size is a no-op
+	hm.Reserve(10)
+	if hm.size != size {
+		t.Errorf("Expected Reserve not to shrink, size went from %d to %d", size, hm.size)
+	}
+}
+
+// Test that Shrink fits the table to its entries
+func TestShrink(t *testing.T) {
+	hm, err := NewHashMap(16, WithLowWatermark(0))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+	for i := 10; i < 1000; i++ {
+		hm.Delete(fmt.Sprintf("key%d", i))
+	}
+
+	if hm.size < 1024 {
+		t.Errorf("Expected no automatic shrink with a zero low watermark, size is %d", hm.size)
+	}
+
+	hm.Shrink()
+
+	if hm.size != 16 {
+		t.Errorf("Expected size 16 after Shrink, got %d", hm.size)
+	}
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if value, _ := hm.Get(key); value != fmt.Sprintf("value%d", i) {
+			t.Errorf("Key '%s' lost after Shrink, got '%s'", key, value)
+		}
+	}
+}
+
+// Test that deletes shrink the table, but not below its initial size
+func TestDelete_AutomaticShrink(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+	peak := hm.size
+
+	for i := 0; i < 990; i++ {
+		hm.Delete(fmt.Sprintf("key%d", i))
+	}
+
+	if hm.size >= peak {
+		t.Errorf("Expected table to shrink from %d after deletes, got %d", peak, hm.size)
+	}
+	if hm.size < 16 {
+		t.Errorf("Expected table not to shrink below its initial size, got %d", hm.size)
+	}
+
+	for i := 990; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if value, _ := hm.Get(key); value != fmt.Sprintf("value%d", i) {
+			t.Errorf("Key '%s' lost after shrinking, got '%s'", key, value)
+		}
+	}
+}
+
+// Test that the table never shrinks below the minimum capacity
+func TestWithMinCapacity(t *testing.T) {
+	hm, err := NewHashMap(16, WithMinCapacity(256))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if hm.size != 256 {
+		t.Errorf("Expected initial size raised to 256, got %d", hm.size)
+	}
+
+	for i := 0; i < 1000; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), "value")
+	}
+	for i := 0; i < 1000; i++ {
+		hm.Delete(fmt.Sprintf("key%d", i))
+	}
+
+	if hm.size != 256 {
+		t.Errorf("Expected table to stop shrinking at 256, got %d", hm.size)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+// Probing selects the sequence of slots visited after a key's home slot.
+type Probing int
+
+const (
+	// LinearProbe visits home, home+1, home+2, ...
+	LinearProbe Probing = iota
+	// QuadraticProbe steps by triangular numbers: home, home+1, home+3,
+	// home+6, ... which visits every slot of a power-of-two table.
+	QuadraticProbe
+	// DoubleHashProbe steps by a second, per-key stride, so keys sharing a
+	// home slot still follow different sequences.
+	DoubleHashProbe
+)
+
+// probeSeq walks the slots of a table for one hash.
+type probeSeq struct {
+	pos     uint64
+	stride  uint64
+	mask    uint64
+	hash    uint64
+	probing Probing
+}
+
+func newProbeSeq(probing Probing, hash, size uint64) probeSeq {
+	return probeSeq{
+		pos:     hash & (size - 1),
+		stride:  1,
+		mask:    size - 1,
+		hash:    hash,
+		probing: probing,
+	}
+}
+
+func (s *probeSeq) next() {
+	// The double hashing stride is derived lazily, since most lookups end
+	// at the home slot. Remixing the hash keeps the stride independent of
+	// the home slot even for weak Hasher implementations, and since the
+	// hash is already seeded the stride needs no seed of its own.
+	if s.probing == DoubleHashProbe && s.stride == 1 {
+		s.stride = mix64(s.hash) | 1
+	}
+
+	s.pos = (s.pos + s.stride) & s.mask
+
+	if s.probing == QuadraticProbe {
+		s.stride++
+	}
+}
+
+func (h *HashMap[K, V]) probe(hash uint64) probeSeq {
+	return newProbeSeq(h.probing, hash, h.size)
+}
+
+// distance returns how many steps of hash's probe sequence it takes to
+// reach slot i.
+func (h *HashMap[K, V]) distance(i, hash uint64) uint64 {
+	if h.probing == LinearProbe {
+		return (i - hash) & (h.size - 1)
+	}
+
+	seq := h.probe(hash)
+	dist := uint64(0)
+
+	for seq.pos != i {
+		seq.next()
+		dist++
+	}
+
+	return dist
+}
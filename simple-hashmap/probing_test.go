@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+var probings = []struct {
+	name    string
+	probing Probing
+}{
+	{"Linear", LinearProbe},
+	{"Quadratic", QuadraticProbe},
+	{"DoubleHash", DoubleHashProbe},
+}
+
+// Test that every probe sequence visits each slot exactly once
+func TestProbeSeq_CoversTable(t *testing.T) {
+	for _, p := range probings {
+		t.Run(p.name, func(t *testing.T) {
+			for _, hash := range []uint64{0, 7, 0xdeadbeef, 1 << 63} {
+				size := uint64(64)
+				seen := make([]bool, size)
+				seq := newProbeSeq(p.probing, hash, size)
+
+				for range size {
+					if seen[seq.pos] {
+						t.Fatalf("Hash %x: slot %d visited twice", hash, seq.pos)
+					}
+					seen[seq.pos] = true
+					seq.next()
+				}
+			}
+		})
+	}
+}
+
+// Test that an unknown probing scheme is rejected
+func TestWithProbing_Invalid(t *testing.T) {
+	hm, err := NewHashMap(64, WithProbing(Probing(42)))
+	if err == nil {
+		t.Error("Expected error for unknown probing, got nil")
+	}
+	if hm != nil {
+		t.Error("Expected nil HashMap for invalid option")
+	}
+}
+
+// Test that Robin Hood hashing is rejected with non-linear probing
+func TestWithProbing_RobinHoodNeedsLinear(t *testing.T) {
+	hm, err := NewHashMap(64, WithStrategy(RobinHood), WithProbing(QuadraticProbe))
+	if err == nil {
+		t.Error("Expected error for Robin Hood with quadratic probing, got nil")
+	}
+	if hm != nil {
+		t.Error("Expected nil HashMap for invalid option")
+	}
+}
+
+// Test every probing scheme against the built-in map, with and without incremental rehash
+func TestProbing_MatchesBuiltinMap(t *testing.T) {
+	for _, p := range probings {
+		for _, incremental := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/incremental=%v", p.name, incremental), func(t *testing.T) {
+				opts := []Option{WithProbing(p.probing)}
+				if incremental {
+					opts = append(opts, WithIncrementalRehash(2))
+				}
+
+				hm, err := NewHashMapOf[int, int](16, opts...)
+				if err != nil {
+					t.Fatalf("Failed to create HashMap: %v", err)
+				}
+				expected := map[int]int{}
+
+				for i := 0; i < 5000; i++ {
+					key := (i * 7919) % 1500
+					if i%3 == 0 {
+						hm.Delete(key)
+						delete(expected, key)
+					} else {
+						hm.Put(key, i)
+						expected[key] = i
+					}
+				}
+
+				if hm.occupied != uint64(len(expected)) {
+					t.Errorf("Expected occupied to be %d, got %d", len(expected), hm.occupied)
+				}
+
+				for key := 0; key < 1500; key++ {
+					value, ok := hm.Lookup(key)
+					expectedValue, expectedOk := expected[key]
+					if ok != expectedOk || value != expectedValue {
+						t.Errorf("Key %d: expected (%d, %v), got (%d, %v)", key, expectedValue, expectedOk, value, ok)
+					}
+				}
+
+				count := 0
+				for range hm.All() {
+					count++
+				}
+				if count != len(expected) {
+					t.Errorf("Expected iteration over %d entries, got %d", len(expected), count)
+				}
+			})
+		}
+	}
+}
+
+// Test that tombstones are reused and purged without growing the table
+func TestProbing_TombstoneChurn(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](64, WithProbing(DoubleHashProbe), WithLowWatermark(0))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		hm.Put(i, i)
+		hm.Delete(i)
+	}
+
+	if hm.size != 64 {
+		t.Errorf("Expected churn to stay within 64 slots, got %d", hm.size)
+	}
+	if hm.occupied+hm.tombstones >= hm.maxLoad {
+		t.Errorf("Expected tombstones to be purged, %d left", hm.tombstones)
+	}
+}
+
+// Test that distance counts the probe steps from the home slot
+func TestDistance(t *testing.T) {
+	for _, p := range probings {
+		t.Run(p.name, func(t *testing.T) {
+			hm, err := NewHashMapOf[int, int](64, WithProbing(p.probing))
+			if err != nil {
+				t.Fatalf("Failed to create HashMap: %v", err)
+			}
+
+			seq := hm.probe(12345)
+
+			for dist := range uint64(10) {
+				if got := hm.distance(seq.pos, 12345); got != dist {
+					t.Errorf("Slot %d: expected distance %d, got %d", seq.pos, dist, got)
+				}
+				seq.next()
+			}
+		})
+	}
+}
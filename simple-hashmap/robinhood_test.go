@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// checkRobinHoodInvariants verifies stored probe distances and that no entry
+// is closer to its home than the entry it follows in the same cluster.
+func checkRobinHoodInvariants(t *testing.T, hm *HashMap[int, int]) {
+	t.Helper()
+
+	mask := hm.size - 1
+
+	for i, entry := range hm.list {
+		if entry == nil {
+			continue
+		}
+
+		if dist := (uint64(i) - entry.hash) & mask; entry.dist != dist {
+			t.Fatalf("Slot %d: stored distance %d, actual %d", i, entry.dist, dist)
+		}
+
+		next := hm.list[(uint64(i)+1)&mask]
+		if next != nil && next.dist > entry.dist+1 {
+			t.Fatalf("Slot %d: next entry has distance %d after %d", i, next.dist, entry.dist)
+		}
+	}
+}
+
+// Test that an unknown strategy is rejected
+func TestWithStrategy_Invalid(t *testing.T) {
+	hm, err := NewHashMap(64, WithStrategy(Strategy(42)))
+	if err == nil {
+		t.Error("Expected error for unknown strategy, got nil")
+	}
+	if hm != nil {
+		t.Error("Expected nil HashMap for invalid option")
+	}
+}
+
+// Test basic puts and gets with Robin Hood hashing
+func TestRobinHood_PutAndGet(t *testing.T) {
+	hm, err := NewHashMap(16, WithStrategy(RobinHood))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 200; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expectedValue := fmt.Sprintf("value%d", i)
+		if value, _ := hm.Get(key); value != expectedValue {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expectedValue, value)
+		}
+	}
+
+	if _, ok := hm.Lookup("missing"); ok {
+		t.Error("Expected missing key to be absent")
+	}
+}
+
+// Test that the table keeps its ordering through inserts, deletes and rehashes
+func TestRobinHood_MatchesBuiltinMap(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](16, WithStrategy(RobinHood), WithLoadFactor(0.9))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	expected := map[int]int{}
+
+	for i := 0; i < 5000; i++ {
+		key := (i * 7919) % 2000
+		if i%4 == 0 {
+			hm.Delete(key)
+			delete(expected, key)
+		} else {
+			hm.Put(key, i)
+			expected[key] = i
+		}
+
+		if i%500 == 0 {
+			checkRobinHoodInvariants(t, hm)
+		}
+	}
+
+	checkRobinHoodInvariants(t, hm)
+
+	if hm.occupied != uint64(len(expected)) {
+		t.Errorf("Expected occupied to be %d, got %d", len(expected), hm.occupied)
+	}
+
+	for key := 0; key < 2000; key++ {
+		value, ok := hm.Lookup(key)
+		expectedValue, expectedOk := expected[key]
+		if ok != expectedOk || value != expectedValue {
+			t.Errorf("Key %d: expected (%d, %v), got (%d, %v)", key, expectedValue, expectedOk, value, ok)
+		}
+	}
+}
+
+// Test that incremental migration keeps the Robin Hood ordering
+func TestRobinHood_WithIncrementalRehash(t *testing.T) {
+	hm, err := NewHashMapOf[int, int](16, WithStrategy(RobinHood), WithIncrementalRehash(1))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		hm.Put(i, i)
+		if i%3 == 0 {
+			hm.Delete(i / 2)
+		}
+	}
+
+	hm.finishMigration()
+	checkRobinHoodInvariants(t, hm)
+
+	for key, value := range hm.All() {
+		if key != value {
+			t.Errorf("Key %d: expected %d, got %d", key, key, value)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+)
+
+// SeededHasher is implemented by Hashers that can also hash from a fixed
+// integer seed, which WithSeed needs to make table layouts reproducible.
+// The hash must depend only on seed and key.
+type SeededHasher[K any] interface {
+	HashSeed(seed uint64, key K) uint64
+}
+
+// HashSeed hashes the key's binary encoding, so it supports the key types
+// snapshots do. Equal keys must encode to equal bytes: floats are
+// normalized so that -0 hashes like 0, while NaN, which is never equal to
+// anything, hashes by its bits. A MarshalBinary that fails hashes like an
+// empty encoding; HashMap rejects such keys before they are stored, see
+// seededKeyCheck.
+func (ComparableHasher[K]) HashSeed(seed uint64, key K) uint64 {
+	var buf [16]byte
+	var v any = key
+
+	switch x := v.(type) {
+	case float64:
+		if x == 0 {
+			v = float64(0)
+		}
+	case float32:
+		if x == 0 {
+			v = float32(0)
+		}
+	}
+
+	data, err := appendBinary(buf[:0], v)
+	if err != nil {
+		return hashBytes(seed, buf[:0])
+	}
+
+	return hashBytes(seed, data)
+}
+
+func (StringHasher) HashSeed(seed uint64, key string) uint64 {
+	return hashBytes(seed, key)
+}
+
+// seededHasherFor returns hasher's SeededHasher, checking up front that it
+// can hash K.
+func seededHasherFor[K comparable](hasher Hasher[K]) (SeededHasher[K], error) {
+	seeded, ok := hasher.(SeededHasher[K])
+	if !ok {
+		return nil, errors.New("Hasher does not support WithSeed.")
+	}
+
+	if _, ok := hasher.(ComparableHasher[K]); ok {
+		var zero K
+		if _, err := appendBinary(nil, zero); err != nil {
+			return nil, errors.New("Key type does not support WithSeed.")
+		}
+	}
+
+	return seeded, nil
+}
+
+// seededKeyCheck returns the key validation a HashMap with WithSeed needs
+// for K, or nil. Keys hashed through MarshalBinary can fail to encode, and
+// that must be an error from Put rather than a lost key.
+func seededKeyCheck[K comparable](hasher Hasher[K]) func(K) error {
+	var zero K
+
+	if _, ok := hasher.(ComparableHasher[K]); !ok {
+		return nil
+	}
+
+	if _, ok := any(zero).(encoding.BinaryMarshaler); !ok {
+		return nil
+	}
+
+	return func(key K) error {
+		if _, err := appendBinary(nil, key); err != nil {
+			return fmt.Errorf("encoding key: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// hashBytes is FNV-1a with the seed folded into its offset basis, finished
+// with mix64. It is fast and reproducible, but anyone who knows the seed
+// can construct colliding keys.
+func hashBytes[T string | []byte](seed uint64, data T) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	x := offset ^ seed
+	for i := 0; i < len(data); i++ {
+		x ^= uint64(data[i])
+		x *= prime
+	}
+
+	return mix64(x)
+}
+
+// mix64 is the murmur3 finalizer. It makes every output bit depend on
+// every input bit, which FNV alone does not do for the low bits.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
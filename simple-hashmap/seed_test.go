@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"testing"
+)
+
+// layout maps every occupied slot to its key.
+func layout[K comparable, V any](h *HashMap[K, V]) map[uint64]K {
+	slots := make(map[uint64]K)
+	for i, entry := range h.list {
+		if entry != nil && entry != h.tomb {
+			slots[uint64(i)] = entry.key
+		}
+	}
+
+	return slots
+}
+
+// Test exact slot positions with a fixed seed. A change here means the
+// seeded hash changed, which breaks every golden output built on it.
+func TestWithSeed_GoldenLayout(t *testing.T) {
+	hm, err := NewHashMap(64, WithSeed(42))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for _, key := range []string{"key1", "key2", "key3", "key4", "key5"} {
+		hm.Put(key, "value")
+	}
+
+	expected := map[uint64]string{12: "key2", 13: "key4", 35: "key1", 44: "key5", 51: "key3"}
+	if got := layout(hm); !maps.Equal(got, expected) {
+		t.Errorf("Expected layout %v, got %v", expected, got)
+	}
+
+	ints, err := NewHashMapOf[int, int](16, WithSeed(7))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 1; i <= 10; i++ {
+		ints.Put(i, i)
+	}
+
+	// 5 hashes to slot 10 and probes past 1 and 2
+	expectedInts := map[uint64]int{2: 4, 3: 9, 4: 8, 6: 6, 9: 3, 10: 1, 11: 2, 12: 5, 14: 7, 15: 10}
+	if got := layout(ints); !maps.Equal(got, expectedInts) {
+		t.Errorf("Expected layout %v, got %v", expectedInts, got)
+	}
+}
+
+// Test that the same seed gives the same layout, also after a snapshot
+func TestWithSeed_Reproducible(t *testing.T) {
+	build := func(seed uint64, probing Probing) *HashMap[string, int] {
+		hm, err := NewHashMapOf[string, int](16, WithSeed(seed), WithProbing(probing))
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		for i := 0; i < 500; i++ {
+			hm.Put(fmt.Sprintf("key%d", i), i)
+		}
+		for i := 0; i < 500; i += 7 {
+			hm.Delete(fmt.Sprintf("key%d", i))
+		}
+		return hm
+	}
+
+	for _, tc := range probings {
+		a, b := build(1, tc.probing), build(1, tc.probing)
+		if !maps.Equal(layout(a), layout(b)) {
+			t.Errorf("%s: expected identical layouts for the same seed", tc.name)
+		}
+
+		if maps.Equal(layout(a), layout(build(2, tc.probing))) {
+			t.Errorf("%s: expected different layouts for different seeds", tc.name)
+		}
+
+		// Snapshots load with the same fixed seed
+		data, _ := a.MarshalBinary()
+		restored, err := NewHashMapOf[string, int](16, WithSeed(1), WithProbing(tc.probing))
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		restored.UnmarshalBinary(data)
+
+		if restored.fixedSeed != 1 || restored.hash("key1") != a.hash("key1") {
+			t.Errorf("%s: expected restored map to keep the seed", tc.name)
+		}
+	}
+}
+
+// Test that seeded ConcurrentHashMaps spread keys over shards the same way
+func TestWithSeed_ConcurrentShards(t *testing.T) {
+	a, err := NewConcurrentHashMap[string, int](8, 64, WithSeed(3))
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	b, err := NewConcurrentHashMap[string, int](8, 64, WithSeed(3))
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		a.Put(fmt.Sprintf("key%d", i), i)
+		b.Put(fmt.Sprintf("key%d", i), i)
+	}
+
+	for i := range a.shards {
+		if !maps.Equal(layout(a.shards[i].m), layout(b.shards[i].m)) {
+			t.Errorf("Expected shard %d to have the same layout in both maps", i)
+		}
+	}
+}
+
+// Test that WithSeed is rejected when keys cannot be hashed reproducibly
+func TestWithSeed_Unsupported(t *testing.T) {
+	if _, err := NewHashMapWithHasher[int, int](16, identityHasher{}, WithSeed(1)); err == nil {
+		t.Error("Expected error for a Hasher without HashSeed, got nil")
+	}
+
+	type point struct{ x, y int }
+	if _, err := NewHashMapOf[point, int](16, WithSeed(1)); err == nil {
+		t.Error("Expected error for a key type without a binary encoding, got nil")
+	}
+}
+
+// Test that -0 and 0, which are equal keys, also share a seeded hash
+func TestWithSeed_NegativeZero(t *testing.T) {
+	hm, err := NewHashMapOf[float64, int](64, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put(0.0, 1)
+	hm.Put(math.Copysign(0, -1), 2)
+
+	if hm.Len() != 1 {
+		t.Errorf("Expected -0 to replace 0, got length %d", hm.Len())
+	}
+	if value, _ := hm.Get(0.0); value != 2 {
+		t.Errorf("Expected 2, got %d", value)
+	}
+}
+
+// failingKey cannot always be encoded
+type failingKey struct {
+	id int
+}
+
+func (k failingKey) MarshalBinary() ([]byte, error) {
+	if k.id < 0 {
+		return nil, errors.New("negative id")
+	}
+
+	return []byte{byte(k.id)}, nil
+}
+
+// Test that a key whose encoding fails is an error, not a panic
+func TestWithSeed_KeyEncodingError(t *testing.T) {
+	hm, err := NewHashMapOf[failingKey, int](64, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if err := hm.Put(failingKey{1}, 1); err != nil {
+		t.Fatalf("Failed to put: %v", err)
+	}
+
+	if err := hm.Put(failingKey{-1}, 2); err == nil {
+		t.Error("Expected error for a key that fails to encode, got nil")
+	}
+
+	if _, ok := hm.Lookup(failingKey{-1}); ok || hm.Len() != 1 {
+		t.Error("Expected the key that failed to encode to be absent")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import "iter"
+
+// HashSet is a set backed by a HashMap with empty values. The algebra
+// methods return new sets and make a single pass over the smaller operand,
+// looking its items up in the larger one.
+type HashSet[T comparable] struct {
+	m *HashMap[T, struct{}]
+}
+
+func NewHashSet[T comparable](initialSize uint64, opts ...Option) (*HashSet[T], error) {
+	m, err := NewHashMapOf[T, struct{}](initialSize, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashSet[T]{m: m}, nil
+}
+
+// Add inserts item and reports whether it was new.
+func (s *HashSet[T]) Add(item T) bool {
+	_, loaded, _ := s.m.LoadOrStore(item, struct{}{})
+	return !loaded
+}
+
+// Remove deletes item and reports whether it was present.
+func (s *HashSet[T]) Remove(item T) bool {
+	deleted, _ := s.m.Delete(item)
+	return deleted
+}
+
+func (s *HashSet[T]) Contains(item T) bool {
+	return s.m.Contains(item)
+}
+
+func (s *HashSet[T]) Len() int {
+	return s.m.Len()
+}
+
+// All iterates over the items under the same rules as HashMap.All.
+func (s *HashSet[T]) All() iter.Seq[T] {
+	return s.m.Keys()
+}
+
+func (s *HashSet[T]) Clone() *HashSet[T] {
+	return &HashSet[T]{m: s.m.Clone()}
+}
+
+// emptyLike returns an empty set configured like s with room for n items.
+func (s *HashSet[T]) emptyLike(n int) *HashSet[T] {
+	return &HashSet[T]{m: s.m.emptyLike(uint64(n))}
+}
+
+func smallerFirst[T comparable](a, b *HashSet[T]) (*HashSet[T], *HashSet[T]) {
+	if a.Len() <= b.Len() {
+		return a, b
+	}
+
+	return b, a
+}
+
+// Union returns the items in s or other.
+func (s *HashSet[T]) Union(other *HashSet[T]) *HashSet[T] {
+	small, large := smallerFirst(s, other)
+
+	result := &HashSet[T]{m: large.m.cloneSized(uint64(large.Len() + small.Len()))}
+
+	for item := range small.All() {
+		result.Add(item)
+	}
+
+	return result
+}
+
+// Intersection returns the items in both s and other.
+func (s *HashSet[T]) Intersection(other *HashSet[T]) *HashSet[T] {
+	small, large := smallerFirst(s, other)
+	result := s.emptyLike(small.Len())
+
+	for item := range small.All() {
+		if large.Contains(item) {
+			result.Add(item)
+		}
+	}
+
+	return result
+}
+
+// Difference returns the items in s that are not in other.
+func (s *HashSet[T]) Difference(other *HashSet[T]) *HashSet[T] {
+	if s.Len() <= other.Len() {
+		result := s.emptyLike(s.Len())
+
+		for item := range s.All() {
+			if !other.Contains(item) {
+				result.Add(item)
+			}
+		}
+
+		return result
+	}
+
+	result := s.Clone()
+	for item := range other.All() {
+		result.Remove(item)
+	}
+
+	return result
+}
+
+// SymmetricDifference returns the items in exactly one of s and other.
+func (s *HashSet[T]) SymmetricDifference(other *HashSet[T]) *HashSet[T] {
+	small, large := smallerFirst(s, other)
+	result := large.Clone()
+
+	for item := range small.All() {
+		if !result.Remove(item) {
+			result.Add(item)
+		}
+	}
+
+	return result
+}
+
+// IsSubset reports whether every item of s is in other.
+func (s *HashSet[T]) IsSubset(other *HashSet[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+
+	for item := range s.All() {
+		if !other.Contains(item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Equal reports whether s and other hold the same items.
+func (s *HashSet[T]) Equal(other *HashSet[T]) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
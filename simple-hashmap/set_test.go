@@ -0,0 +1,180 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func sortedItems(s *HashSet[int]) []int {
+	return slices.Sorted(s.All())
+}
+
+// Test Add, Contains and Remove on a single set
+func TestHashSet_Basic(t *testing.T) {
+	s, err := NewHashSet[int](4)
+	if err != nil {
+		t.Fatalf("Failed to create HashSet: %v", err)
+	}
+
+	if !s.Add(1) || !s.Add(2) || s.Add(1) {
+		t.Error("Expected Add to report only new items")
+	}
+
+	if !s.Contains(1) || s.Contains(3) {
+		t.Error("Unexpected Contains result")
+	}
+
+	if !s.Remove(1) || s.Remove(1) {
+		t.Error("Expected Remove to report only present items")
+	}
+
+	if s.Len() != 1 || !slices.Equal(sortedItems(s), []int{2}) {
+		t.Errorf("Expected [2], got %v", sortedItems(s))
+	}
+}
+
+// Test set algebra in both operand orders
+func TestHashSet_Algebra(t *testing.T) {
+	small, err := NewHashSet[int](4)
+	if err != nil {
+		t.Fatalf("Failed to create HashSet: %v", err)
+	}
+	large, err := NewHashSet[int](4)
+	if err != nil {
+		t.Fatalf("Failed to create HashSet: %v", err)
+	}
+
+	for _, item := range []int{1, 2, 3, 4} {
+		small.Add(item)
+	}
+	for _, item := range []int{3, 4, 5, 6, 7, 8} {
+		large.Add(item)
+	}
+
+	testCases := []struct {
+		name     string
+		result   *HashSet[int]
+		expected []int
+	}{
+		{"Union", small.Union(large), []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"UnionReversed", large.Union(small), []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"Intersection", small.Intersection(large), []int{3, 4}},
+		{"IntersectionReversed", large.Intersection(small), []int{3, 4}},
+		{"Difference", small.Difference(large), []int{1, 2}},
+		{"DifferenceReversed", large.Difference(small), []int{5, 6, 7, 8}},
+		{"SymmetricDifference", small.SymmetricDifference(large), []int{1, 2, 5, 6, 7, 8}},
+		{"SymmetricDifferenceReversed", large.SymmetricDifference(small), []int{1, 2, 5, 6, 7, 8}},
+		{"SelfSymmetricDifference", small.SymmetricDifference(small), nil},
+	}
+
+	for _, tc := range testCases {
+		if got := sortedItems(tc.result); !slices.Equal(got, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+
+	// The operands are left unchanged
+	if !slices.Equal(sortedItems(small), []int{1, 2, 3, 4}) || large.Len() != 6 {
+		t.Errorf("Expected operands to be unchanged, got %v and %v", sortedItems(small), sortedItems(large))
+	}
+}
+
+// Test that Union copies the larger set straight into a table big enough
+// for both, without growing it afterwards
+func TestHashSet_UnionSizedOnce(t *testing.T) {
+	small, err := NewHashSet[int](4)
+	if err != nil {
+		t.Fatalf("Failed to create HashSet: %v", err)
+	}
+
+	large, err := NewHashSet[int](4)
+	if err != nil {
+		t.Fatalf("Failed to create HashSet: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		large.Add(i)
+	}
+	for i := 1000; i < 1600; i++ {
+		small.Add(i)
+	}
+
+	union := small.Union(large)
+
+	if union.Len() != 1600 {
+		t.Errorf("Expected 1600 items, got %d", union.Len())
+	}
+	if union.m.rehashes != large.m.rehashes {
+		t.Errorf("Expected no rehash while building the union, got %d more", union.m.rehashes-large.m.rehashes)
+	}
+}
+
+// Test IsSubset and Equal, including the empty set
+func TestHashSet_SubsetAndEqual(t *testing.T) {
+	var sets [6]*HashSet[int]
+	for i, items := range [][]int{{1, 2}, {1, 2, 3}, {}, {2, 1}, {}, {1, 4}} {
+		s, err := NewHashSet[int](4)
+		if err != nil {
+			t.Fatalf("Failed to create HashSet: %v", err)
+		}
+
+		for _, item := range items {
+			s.Add(item)
+		}
+		sets[i] = s
+	}
+
+	a, b, empty := sets[0], sets[1], sets[2]
+
+	if !a.IsSubset(b) || b.IsSubset(a) || !empty.IsSubset(a) {
+		t.Error("Unexpected IsSubset result")
+	}
+
+	if a.Equal(b) || !a.Equal(sets[3]) || !empty.Equal(sets[4]) {
+		t.Error("Unexpected Equal result")
+	}
+
+	if sets[5].IsSubset(b) {
+		t.Error("Expected a set with an extra item not to be a subset")
+	}
+}
+
+// Test that a clone and its original change independently
+func TestHashMap_Clone(t *testing.T) {
+	for _, tc := range probings {
+		hm, err := NewHashMapOf[int, int](8, WithProbing(tc.probing), WithIncrementalRehash(1))
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		for i := 0; i < 100; i++ {
+			hm.Put(i, i)
+		}
+		for i := 0; i < 100; i += 3 {
+			hm.Delete(i)
+		}
+
+		clone := hm.Clone()
+		clone.Put(1, -1)
+		clone.Put(1000, 1000)
+		clone.Delete(2)
+
+		if value, _ := hm.Get(1); value != 1 || hm.Contains(1000) || !hm.Contains(2) {
+			t.Errorf("%s: expected original to be unaffected by the clone", tc.name)
+		}
+
+		for i := 0; i < 100; i++ {
+			if i == 1 || i == 2 {
+				continue
+			}
+
+			if value, ok := clone.Lookup(i); ok != (i%3 != 0) || (ok && value != i) {
+				t.Errorf("%s: unexpected (%d, %v) for key %d in clone", tc.name, value, ok, i)
+			}
+		}
+
+		if clone.Len() != hm.Len() {
+			t.Errorf("%s: expected clone Len %d, got %d", tc.name, hm.Len(), clone.Len())
+		}
+	}
+}
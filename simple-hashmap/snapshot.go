@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Snapshot format, all integers little endian:
+//
+//	magic    "HMAP"
+//	version  uint8
+//	count    uint64
+//	count records of
+//	  key    uvarint length + bytes
+//	  value  uvarint length + bytes
+//	crc32    uint32, IEEE checksum of everything above
+//
+// Keys and values are encoded by appendBinary. The maphash seed is not
+// stored: it cannot be restored, and a loaded map rehashes every key with
+// a fresh seed anyway.
+const (
+	snapshotMagic   = "HMAP"
+	snapshotVersion = 1
+
+	// A corrupt count must not make ReadFrom allocate a huge table up front.
+	snapshotMaxReserve = 1 << 20
+)
+
+var ErrInvalidSnapshot = errors.New("invalid HashMap snapshot")
+
+func (h *HashMap[K, V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if _, err := h.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (h *HashMap[K, V]) UnmarshalBinary(data []byte) error {
+	_, err := h.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// WriteTo streams a snapshot of the map to w without building it in memory.
+func (h *HashMap[K, V]) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(counter, crc))
+
+	// Expired entries are left out, so count the rest. Both passes use the
+	// same time, or an entry expiring in between would be counted but not
+	// written.
+	now := h.now()
+	count := uint64(0)
+	for range h.entriesAt(now) {
+		count++
+	}
+
+	header := append([]byte(snapshotMagic), snapshotVersion)
+	header = binary.LittleEndian.AppendUint64(header, count)
+	bw.Write(header)
+
+	var buf []byte
+	var err error
+
+	for entry := range h.entriesAt(now) {
+		buf = buf[:0]
+
+		if buf, err = appendField(buf, entry.key); err != nil {
+			return counter.n, fmt.Errorf("encoding key: %w", err)
+		}
+
+		if buf, err = appendField(buf, entry.value); err != nil {
+			return counter.n, fmt.Errorf("encoding value: %w", err)
+		}
+
+		if _, err := bw.Write(buf); err != nil {
+			return counter.n, err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return counter.n, err
+	}
+
+	_, err = counter.Write(binary.LittleEndian.AppendUint32(nil, crc.Sum32()))
+	return counter.n, err
+}
+
+// appendField appends v with a uvarint length prefix.
+func appendField(buf []byte, v any) ([]byte, error) {
+	start := len(buf)
+
+	buf, err := appendBinary(buf, v)
+	if err != nil {
+		return buf, err
+	}
+
+	// Encode first, then move the payload behind its length prefix.
+	payload := len(buf) - start
+	prefix := binary.AppendUvarint(nil, uint64(payload))
+	buf = append(buf, prefix...)
+	copy(buf[start+len(prefix):], buf[start:start+payload])
+	copy(buf[start:], prefix)
+	return buf, nil
+}
+
+// ReadFrom replaces the contents of the map with a snapshot read from r.
+// The map keeps its configuration but gets a fresh seed. On error it is
+// left unchanged. r is read through a buffer, so bytes after the snapshot
+// may be consumed.
+func (h *HashMap[K, V]) ReadFrom(r io.Reader) (int64, error) {
+	sr := &snapshotReader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+
+	header := make([]byte, len(snapshotMagic)+1+8)
+	if err := sr.readFull(header); err != nil {
+		return sr.n, err
+	}
+
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return sr.n, fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	}
+
+	if version := header[len(snapshotMagic)]; version != snapshotVersion {
+		return sr.n, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, version)
+	}
+
+	count := binary.LittleEndian.Uint64(header[len(snapshotMagic)+1:])
+	next := h.emptyLike(min(count, snapshotMaxReserve))
+
+	var buf []byte
+	for range count {
+		var key K
+		var value V
+		var err error
+
+		if buf, err = sr.readField(buf, &key); err != nil {
+			return sr.n, fmt.Errorf("decoding key: %w", err)
+		}
+
+		if buf, err = sr.readField(buf, &value); err != nil {
+			return sr.n, fmt.Errorf("decoding value: %w", err)
+		}
+
+		if err := next.Put(key, value); err != nil {
+			return sr.n, err
+		}
+	}
+
+	sum := sr.crc.Sum32()
+	footer := make([]byte, 4)
+	if err := sr.readFull(footer); err != nil {
+		return sr.n, err
+	}
+
+	if binary.LittleEndian.Uint32(footer) != sum {
+		return sr.n, fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+
+	if next.occupied != count {
+		return sr.n, fmt.Errorf("%w: duplicate keys", ErrInvalidSnapshot)
+	}
+
+	h.replaceWith(next)
+	return sr.n, nil
+}
+
+// replaceWith takes over the table of next, keeping modCount increasing so
+// running iterators notice, and subscribers, which are told about every
+// key that goes and comes.
+func (h *HashMap[K, V]) replaceWith(next *HashMap[K, V]) {
+	if h.watch != nil {
+		for e := range h.entries() {
+			h.notify(Event[K, V]{Kind: EventDelete, Key: e.key, Old: e.value, HasOld: true})
+		}
+
+		for e := range next.entries() {
+			h.notify(Event[K, V]{Kind: EventPut, Key: e.key, New: e.value})
+		}
+	}
+
+	next.modCount = h.modCount + 1
+	next.watch = h.watch
+	*h = *next
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// snapshotReader checksums and counts everything it reads.
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int64
+}
+
+func (s *snapshotReader) ReadByte() (byte, error) {
+	b, err := s.r.ReadByte()
+	if err != nil {
+		return b, err
+	}
+
+	s.crc.Write([]byte{b})
+	s.n++
+	return b, nil
+}
+
+func (s *snapshotReader) readFull(buf []byte) error {
+	n, err := io.ReadFull(s.r, buf)
+	s.crc.Write(buf[:n])
+	s.n += int64(n)
+
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// readField reads a length-prefixed field into dst, reusing buf.
+func (s *snapshotReader) readField(buf []byte, dst any) ([]byte, error) {
+	length, err := binary.ReadUvarint(s)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return buf, err
+	}
+
+	// Grow in steps so a corrupt length fails on EOF instead of allocating.
+	buf = buf[:0]
+	for remaining := length; remaining > 0; {
+		chunk := min(remaining, 1<<20)
+		start := len(buf)
+		buf = append(buf, make([]byte, chunk)...)
+
+		if err := s.readFull(buf[start:]); err != nil {
+			return buf, err
+		}
+
+		remaining -= chunk
+	}
+
+	return buf, decodeBinary(buf, dst)
+}
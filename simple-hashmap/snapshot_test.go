@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// Test that a snapshot loads back into an equal map with a fresh seed
+func TestSnapshot_RoundTrip(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 500; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+	hm.Put("empty", "")
+
+	data, err := hm.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	loaded, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if loaded.Len() != hm.Len() {
+		t.Errorf("Expected %d entries, got %d", hm.Len(), loaded.Len())
+	}
+
+	for key, value := range hm.All() {
+		if actual, ok := loaded.Lookup(key); !ok || actual != value {
+			t.Errorf("Key '%s': expected ('%s', true), got ('%s', %v)", key, value, actual, ok)
+		}
+	}
+
+	if loaded.seed == hm.seed {
+		t.Error("Expected the loaded map to use a fresh seed")
+	}
+}
+
+// Test snapshots of non-string types, loaded into a zero HashMap
+func TestSnapshot_TypedValues(t *testing.T) {
+	hm, err := NewHashMapOf[int, float64](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := -50; i < 50; i++ {
+		hm.Put(i, float64(i)/4)
+	}
+
+	data, err := hm.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	// A zero HashMap can be unmarshaled into directly
+	var loaded HashMap[int, float64]
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	for i := -50; i < 50; i++ {
+		if value, ok := loaded.Lookup(i); !ok || value != float64(i)/4 {
+			t.Errorf("Key %d: expected (%v, true), got (%v, %v)", i, float64(i)/4, value, ok)
+		}
+	}
+
+	loaded.Put(1000, 1)
+	if loaded.Len() != 101 {
+		t.Errorf("Expected loaded map to accept new keys, got length %d", loaded.Len())
+	}
+}
+
+// Test that loading keeps the options and key validation of the map
+func TestSnapshot_KeepsConfiguration(t *testing.T) {
+	hm, err := NewHashMap(16, WithLoadFactor(0.5), WithStrategy(RobinHood))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("key", "value")
+
+	data, _ := hm.MarshalBinary()
+	if err := hm.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if hm.loadFactor != 0.5 || hm.strategy != RobinHood {
+		t.Errorf("Expected configuration to survive loading, got load factor %v and strategy %v", hm.loadFactor, hm.strategy)
+	}
+
+	// Key validation still applies to loaded string maps
+	if err := hm.Put("", "value"); err == nil {
+		t.Error("Expected error for empty key after loading, got nil")
+	}
+}
+
+// Test writing and reading a snapshot as a stream
+func TestSnapshot_StreamingThroughPipe(t *testing.T) {
+	hm, err := NewHashMapOf[uint32, string](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := uint32(0); i < 2000; i++ {
+		hm.Put(i, fmt.Sprint(i))
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		_, err := hm.WriteTo(w)
+		w.CloseWithError(err)
+	}()
+
+	var loaded HashMap[uint32, string]
+	if _, err := loaded.ReadFrom(r); err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+
+	if loaded.Len() != 2000 {
+		t.Errorf("Expected 2000 entries, got %d", loaded.Len())
+	}
+}
+
+// Test the byte counts returned by WriteTo and ReadFrom
+func TestSnapshot_ByteCounts(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("name", "Alice")
+
+	var buf bytes.Buffer
+	written, err := hm.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	if written != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", written, buf.Len())
+	}
+
+	read, err := hm.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if read != written {
+		t.Errorf("ReadFrom reported %d bytes, expected %d", read, written)
+	}
+}
+
+// Test that a damaged snapshot is rejected and leaves the map unchanged
+func TestSnapshot_Corruption(t *testing.T) {
+	hm, err := NewHashMap(16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		hm.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	data, _ := hm.MarshalBinary()
+
+	testCases := []struct {
+		name    string
+		corrupt func([]byte) []byte
+	}{
+		{"bad magic", func(b []byte) []byte { b[0] = 'X'; return b }},
+		{"bad version", func(b []byte) []byte { b[4] = 99; return b }},
+		{"flipped payload bit", func(b []byte) []byte { b[20] ^= 1; return b }},
+		{"flipped checksum bit", func(b []byte) []byte { b[len(b)-1] ^= 1; return b }},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-10] }},
+		{"empty", func(b []byte) []byte { return nil }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := NewHashMap(16)
+			if err != nil {
+				t.Fatalf("Failed to create HashMap: %v", err)
+			}
+
+			target.Put("existing", "value")
+
+			err = target.UnmarshalBinary(tc.corrupt(bytes.Clone(data)))
+			if err == nil {
+				t.Fatal("Expected error for corrupt snapshot, got nil")
+			}
+
+			// A failed load leaves the map untouched
+			if value, _ := target.Get("existing"); value != "value" || target.Len() != 1 {
+				t.Errorf("Expected map to be unchanged after failed load")
+			}
+		})
+	}
+
+	err = hm.UnmarshalBinary(append([]byte("XXXX"), data[4:]...))
+	if !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot, got %v", err)
+	}
+}
+
+// Test that values with no encoding cannot be snapshotted
+func TestSnapshot_UnsupportedType(t *testing.T) {
+	hm, err := NewHashMapOf[string, chan int](16)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("ch", make(chan int))
+
+	if _, err := hm.MarshalBinary(); err == nil {
+		t.Error("Expected error for unsupported value type, got nil")
+	}
+}
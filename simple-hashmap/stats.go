@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Stats describes the layout of a HashMap's table.
+type Stats struct {
+	Capacity   uint64  // slots in the table
+	Len        int     // live entries, including expired ones not yet reclaimed
+	Tombstones uint64  // deleted slots left by non-linear probing
+	Load       float64 // Len / Capacity
+	MaxLoad    uint64  // entries plus tombstones that trigger the next rehash
+	Rehashes   uint64  // table rebuilds since creation, shrinks included
+
+	// Entries still waiting in the old table of an incremental rehash.
+	// They are not part of the probe statistics below.
+	Migrating uint64
+
+	// A successful lookup of an entry at distance d from its home slot
+	// visits d+1 slots, its probe length.
+	AvgProbeLength float64
+	MaxProbeLength uint64
+
+	// ProbeDistances[d] counts the entries d steps along their probe
+	// sequence.
+	ProbeDistances []uint64
+
+	// ClusterLengths[n] counts the runs of exactly n adjacent used slots,
+	// tombstones included. Under linear probing a miss scans to the end of
+	// its cluster, so long clusters mean slow misses.
+	ClusterLengths []uint64
+}
+
+// Stats walks the table and reports its occupancy and probe statistics.
+// It does not modify the map.
+func (h *HashMap[K, V]) Stats() Stats {
+	s := Stats{
+		Capacity:   h.size,
+		Len:        h.Len(),
+		Tombstones: h.tombstones,
+		MaxLoad:    h.maxLoad,
+		Rehashes:   h.rehashes,
+	}
+
+	if h.size > 0 {
+		s.Load = float64(h.occupied) / float64(h.size)
+	}
+
+	for _, entry := range h.old {
+		if entry != nil && entry != h.tomb {
+			s.Migrating++
+		}
+	}
+
+	total, counted := uint64(0), uint64(0)
+
+	for _, entry := range h.list {
+		if entry == nil || entry == h.tomb {
+			continue
+		}
+
+		for uint64(len(s.ProbeDistances)) <= entry.dist {
+			s.ProbeDistances = append(s.ProbeDistances, 0)
+		}
+
+		s.ProbeDistances[entry.dist]++
+		total += entry.dist + 1
+		counted++
+		s.MaxProbeLength = max(s.MaxProbeLength, entry.dist+1)
+	}
+
+	if counted > 0 {
+		s.AvgProbeLength = float64(total) / float64(counted)
+	}
+
+	s.ClusterLengths = h.clusterLengths()
+	return s
+}
+
+// clusterLengths starts counting after an empty slot, so a cluster that
+// wraps around the end of the table is counted once.
+func (h *HashMap[K, V]) clusterLengths() []uint64 {
+	start := uint64(0)
+	for start < h.size && h.list[start] != nil {
+		start++
+	}
+
+	if start == h.size {
+		if h.size == 0 {
+			return nil
+		}
+
+		lengths := make([]uint64, h.size+1)
+		lengths[h.size] = 1
+		return lengths
+	}
+
+	var lengths []uint64
+	run := uint64(0)
+
+	for k := uint64(1); k <= h.size; k++ {
+		if h.list[(start+k)&(h.size-1)] != nil {
+			run++
+			continue
+		}
+
+		if run > 0 {
+			for uint64(len(lengths)) <= run {
+				lengths = append(lengths, 0)
+			}
+
+			lengths[run]++
+			run = 0
+		}
+	}
+
+	return lengths
+}
+
+// Dump writes a map of the table to w, 64 slots per line. Each slot is '.'
+// when empty, 'x' for a tombstone, the entry's probe distance for
+// distances 0 to 9, and '+' beyond that. Rows of digits above zero show
+// clusters forming.
+func (h *HashMap[K, V]) Dump(w io.Writer) error {
+	const perLine = 64
+
+	bw := bufio.NewWriter(w)
+	s := h.Stats()
+
+	fmt.Fprintf(bw, "capacity %d, len %d, load %.3f, tombstones %d, rehashes %d, avg probe %.2f, max probe %d\n",
+		s.Capacity, s.Len, s.Load, s.Tombstones, s.Rehashes, s.AvgProbeLength, s.MaxProbeLength)
+
+	line := make([]byte, 0, perLine)
+
+	for start := uint64(0); start < h.size; start += perLine {
+		line = line[:0]
+
+		for i := start; i < min(start+perLine, h.size); i++ {
+			switch entry := h.list[i]; {
+			case entry == nil:
+				line = append(line, '.')
+			case entry == h.tomb:
+				line = append(line, 'x')
+			case entry.dist < 10:
+				line = append(line, byte('0'+entry.dist))
+			default:
+				line = append(line, '+')
+			}
+		}
+
+		fmt.Fprintf(bw, "%8d %s\n", start, line)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Test probe distances, clusters and rehash counts reported by Stats
+func TestHashMap_Stats(t *testing.T) {
+	hm, err := NewHashMapWithHasher[int, int](16, identityHasher{})
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	// Keys 0-3 sit in their home slots, 16 and 17 collide with 0 and 1
+	for _, key := range []int{0, 1, 2, 3, 16, 17, 8} {
+		hm.Put(key, key)
+	}
+
+	s := hm.Stats()
+
+	if s.Capacity != 16 || s.Len != 7 || s.Load != 7.0/16 || s.Rehashes != 0 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+
+	// 16 lands in slot 4 and 17 in slot 5, four steps from home
+	expectedDistances := []uint64{5, 0, 0, 0, 2}
+	if !slices.Equal(s.ProbeDistances, expectedDistances) {
+		t.Errorf("Expected distances %v, got %v", expectedDistances, s.ProbeDistances)
+	}
+
+	if s.MaxProbeLength != 5 || s.AvgProbeLength != 15.0/7 {
+		t.Errorf("Expected max probe 5 and avg 15/7, got %d and %f", s.MaxProbeLength, s.AvgProbeLength)
+	}
+
+	// One cluster of 6 in slots 0-5 and one of 1 in slot 8
+	expectedClusters := []uint64{0, 1, 0, 0, 0, 0, 1}
+	if !slices.Equal(s.ClusterLengths, expectedClusters) {
+		t.Errorf("Expected clusters %v, got %v", expectedClusters, s.ClusterLengths)
+	}
+
+	for i := 100; i < 110; i++ {
+		hm.Put(i, i)
+	}
+
+	if s := hm.Stats(); s.Rehashes != 1 || s.Capacity != 32 {
+		t.Errorf("Expected one rehash to 32 slots, got %d to %d", s.Rehashes, s.Capacity)
+	}
+}
+
+// Test that a cluster wrapping past the last slot is counted once
+func TestHashMap_StatsWrappedCluster(t *testing.T) {
+	hm, err := NewHashMapWithHasher[int, int](8, identityHasher{}, WithLoadFactor(0.9))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	// 7 and 15 fill slots 7 and 0, a single cluster across the end
+	hm.Put(7, 7)
+	hm.Put(15, 15)
+
+	if s := hm.Stats(); !slices.Equal(s.ClusterLengths, []uint64{0, 0, 1}) {
+		t.Errorf("Expected one cluster of 2, got %v", s.ClusterLengths)
+	}
+}
+
+// Test the summary and slot rows written by Dump
+func TestHashMap_Dump(t *testing.T) {
+	hm, err := NewHashMapWithHasher[int, int](128, identityHasher{}, WithProbing(QuadraticProbe))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put(0, 0)
+	hm.Put(128, 128)
+	hm.Put(65, 65)
+	hm.Delete(0)
+
+	var sb strings.Builder
+	if err := hm.Dump(&sb); err != nil {
+		t.Fatalf("Failed to dump: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a summary and 2 rows, got %q", sb.String())
+	}
+
+	if !strings.Contains(lines[0], "capacity 128, len 2") {
+		t.Errorf("Unexpected summary %q", lines[0])
+	}
+
+	if row := strings.Fields(lines[1])[1]; !strings.HasPrefix(row, "x1..") {
+		t.Errorf("Expected tombstone then distance 1 in first row, got %q", row)
+	}
+
+	if row := strings.Fields(lines[2])[1]; !strings.HasPrefix(row, ".0..") || len(row) != 64 {
+		t.Errorf("Expected 65 in its home slot in second row, got %q", row)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"errors"
+	"hash/maphash"
+	"math/bits"
+)
+
+// SwissMap is an open-addressing map laid out like Abseil's SwissTable and
+// the Go 1.24 runtime map. Slots are grouped by eight, and each group keeps
+// one control byte per slot packed into a uint64: either emptyCtrl,
+// deletedCtrl, or the low 7 bits of the key's hash. A probe compares all
+// eight control bytes at once with SWAR bit tricks and only touches keys
+// whose fragment matches. Keys and values are stored inline in the group,
+// so there are no per-entry pointers for probes to chase or the GC to scan.
+type SwissMap[K comparable, V any] struct {
+	groups     []swissGroup[K, V]
+	mask       uint64 // len(groups) - 1
+	occupied   uint64
+	growthLeft uint64 // inserts left before a rehash, tombstones included
+	seed       maphash.Seed
+	hasher     Hasher[K]
+}
+
+const (
+	groupSize   = 8
+	emptyCtrl   = 0x80
+	deletedCtrl = 0xFE
+
+	bitsetLSB = 0x0101010101010101
+	bitsetMSB = 0x8080808080808080
+
+	// Groups are filled to 7/8 before growing, like the runtime map.
+	swissMaxLoadNum = 7
+	swissMaxLoadDen = 8
+)
+
+type swissGroup[K comparable, V any] struct {
+	ctrl   uint64
+	keys   [groupSize]K
+	values [groupSize]V
+}
+
+// bitset has the high bit of byte i set for every matching slot i.
+type bitset uint64
+
+func (b bitset) first() uint64 {
+	return uint64(bits.TrailingZeros64(uint64(b))) / 8
+}
+
+func (b bitset) removeFirst() bitset {
+	return b & (b - 1)
+}
+
+// match returns the slots whose control byte equals h2. It may report a
+// false positive next to a real match, which the key comparison filters.
+func (g *swissGroup[K, V]) match(h2 uint8) bitset {
+	x := g.ctrl ^ (bitsetLSB * uint64(h2))
+	return bitset((x - bitsetLSB) &^ x & bitsetMSB)
+}
+
+// matchEmpty relies on emptyCtrl being the only control byte with the high
+// bit set and bit 1 clear; shifting by 6 moves bit 1 under the high bit.
+func (g *swissGroup[K, V]) matchEmpty() bitset {
+	return bitset(g.ctrl &^ (g.ctrl << 6) & bitsetMSB)
+}
+
+// matchEmptyOrDeleted matches every control byte with the high bit set.
+func (g *swissGroup[K, V]) matchEmptyOrDeleted() bitset {
+	return bitset(g.ctrl & bitsetMSB)
+}
+
+func (g *swissGroup[K, V]) setCtrl(i uint64, c uint8) {
+	shift := i * 8
+	g.ctrl = g.ctrl&^(0xFF<<shift) | uint64(c)<<shift
+}
+
+func NewSwissMap[K comparable, V any](initialSize uint64) (*SwissMap[K, V], error) {
+	return NewSwissMapWithHasher[K, V](initialSize, defaultHasher[K]())
+}
+
+func NewSwissMapWithHasher[K comparable, V any](initialSize uint64, hasher Hasher[K]) (*SwissMap[K, V], error) {
+	if initialSize < 1 {
+		return nil, errors.New("Invalid size.")
+	}
+
+	if hasher == nil {
+		return nil, errors.New("Invalid hasher.")
+	}
+
+	s := &SwissMap[K, V]{
+		seed:   maphash.MakeSeed(),
+		hasher: hasher,
+	}
+
+	s.init(nextPowerOfTwo((initialSize + groupSize - 1) / groupSize))
+	return s, nil
+}
+
+func (s *SwissMap[K, V]) init(numGroups uint64) {
+	s.groups = make([]swissGroup[K, V], numGroups)
+	s.mask = numGroups - 1
+	s.occupied = 0
+	s.growthLeft = numGroups * groupSize * swissMaxLoadNum / swissMaxLoadDen
+
+	for i := range s.groups {
+		s.groups[i].ctrl = bitsetLSB * emptyCtrl
+	}
+}
+
+// splitHash uses the low 7 bits as the control fragment and the rest to
+// pick the first group.
+func splitHash(hash uint64) (uint64, uint8) {
+	return hash >> 7, uint8(hash & 0x7F)
+}
+
+// find returns the group and slot holding key.
+func (s *SwissMap[K, V]) find(key K, hash uint64) (*swissGroup[K, V], uint64, bool) {
+	h1, h2 := splitHash(hash)
+	g := h1 & s.mask
+
+	// Triangular probing visits every group once in a power-of-two table.
+	for probe := uint64(1); probe <= uint64(len(s.groups)); probe++ {
+		group := &s.groups[g]
+
+		for m := group.match(h2); m != 0; m = m.removeFirst() {
+			i := m.first()
+
+			if s.hasher.Equal(group.keys[i], key) {
+				return group, i, true
+			}
+		}
+
+		// An empty slot means key was never pushed past this group.
+		if group.matchEmpty() != 0 {
+			return nil, 0, false
+		}
+
+		g = (g + probe) & s.mask
+	}
+
+	return nil, 0, false
+}
+
+// findInsertSlot returns the first empty or deleted slot on key's probe
+// sequence.
+func (s *SwissMap[K, V]) findInsertSlot(hash uint64) (*swissGroup[K, V], uint64) {
+	h1, _ := splitHash(hash)
+	g := h1 & s.mask
+
+	for probe := uint64(1); probe <= uint64(len(s.groups)); probe++ {
+		group := &s.groups[g]
+
+		if m := group.matchEmptyOrDeleted(); m != 0 {
+			return group, m.first()
+		}
+
+		g = (g + probe) & s.mask
+	}
+
+	panic("SwissMap is full")
+}
+
+func (s *SwissMap[K, V]) Put(key K, value V) error {
+	hash := s.hasher.Hash(s.seed, key)
+
+	if group, i, found := s.find(key, hash); found {
+		group.values[i] = value
+		return nil
+	}
+
+	if s.growthLeft == 0 {
+		s.rehash()
+	}
+
+	group, i := s.findInsertSlot(hash)
+
+	// Reusing a tombstone does not use up any growth budget.
+	if group.ctrl>>(i*8)&0xFF == emptyCtrl {
+		s.growthLeft--
+	}
+
+	_, h2 := splitHash(hash)
+	group.setCtrl(i, h2)
+	group.keys[i] = key
+	group.values[i] = value
+	s.occupied++
+	return nil
+}
+
+func (s *SwissMap[K, V]) Get(key K) (V, error) {
+	value, _ := s.Lookup(key)
+	return value, nil
+}
+
+func (s *SwissMap[K, V]) Lookup(key K) (V, bool) {
+	var zero V
+
+	group, i, found := s.find(key, s.hasher.Hash(s.seed, key))
+	if !found {
+		return zero, false
+	}
+
+	return group.values[i], true
+}
+
+func (s *SwissMap[K, V]) Delete(key K) (bool, error) {
+	var zeroKey K
+	var zeroValue V
+
+	group, i, found := s.find(key, s.hasher.Hash(s.seed, key))
+	if !found {
+		return false, nil
+	}
+
+	// If the group still has an empty slot no probe ever continued past it,
+	// so the slot can become empty again. Otherwise a tombstone keeps later
+	// probe sequences intact.
+	if group.matchEmpty() != 0 {
+		group.setCtrl(i, emptyCtrl)
+		s.growthLeft++
+	} else {
+		group.setCtrl(i, deletedCtrl)
+	}
+
+	group.keys[i] = zeroKey
+	group.values[i] = zeroValue
+	s.occupied--
+	return true, nil
+}
+
+func (s *SwissMap[K, V]) Len() int {
+	return int(s.occupied)
+}
+
+// rehash doubles the table, or rebuilds it at the same size when most of
+// the used budget is tombstones.
+func (s *SwissMap[K, V]) rehash() {
+	old := s.groups
+	capacity := uint64(len(old)) * groupSize
+
+	numGroups := uint64(len(old))
+	if s.occupied >= capacity*swissMaxLoadNum/swissMaxLoadDen/2 {
+		numGroups <<= 1
+	}
+
+	s.init(numGroups)
+
+	for g := range old {
+		group := &old[g]
+
+		for m := bitset(^group.ctrl & bitsetMSB); m != 0; m = m.removeFirst() {
+			i := m.first()
+			hash := s.hasher.Hash(s.seed, group.keys[i])
+			_, h2 := splitHash(hash)
+
+			dst, j := s.findInsertSlot(hash)
+			dst.setCtrl(j, h2)
+			dst.keys[j] = group.keys[i]
+			dst.values[j] = group.values[i]
+			s.occupied++
+			s.growthLeft--
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Test matching control bytes, empty and deleted slots within a group
+func TestSwissGroup_Match(t *testing.T) {
+	var g swissGroup[int, int]
+	g.ctrl = bitsetLSB * emptyCtrl
+
+	g.setCtrl(1, 0x12)
+	g.setCtrl(3, deletedCtrl)
+	g.setCtrl(6, 0x12)
+
+	var found []uint64
+	for m := g.match(0x12); m != 0; m = m.removeFirst() {
+		found = append(found, m.first())
+	}
+	if fmt.Sprint(found) != "[1 6]" {
+		t.Errorf("Expected match in slots [1 6], got %v", found)
+	}
+
+	if m := g.matchEmpty(); m.first() != 0 || bitsCount(m) != 5 {
+		t.Errorf("Expected 5 empty slots starting at 0, got %08x", uint64(m))
+	}
+	if m := g.matchEmptyOrDeleted(); bitsCount(m) != 6 {
+		t.Errorf("Expected 6 empty or deleted slots, got %08x", uint64(m))
+	}
+}
+
+func bitsCount(b bitset) int {
+	count := 0
+	for ; b != 0; b = b.removeFirst() {
+		count++
+	}
+	return count
+}
+
+// Test that a zero initial size is rejected
+func TestNewSwissMap_InvalidSize(t *testing.T) {
+	s, err := NewSwissMap[string, string](0)
+	if err == nil {
+		t.Error("Expected error for initial size 0, got nil")
+	}
+	if s != nil {
+		t.Error("Expected nil SwissMap for invalid size")
+	}
+}
+
+// Test basic puts, gets, updates and deletes
+func TestSwissMap_PutGetDelete(t *testing.T) {
+	s, err := NewSwissMap[string, string](8)
+	if err != nil {
+		t.Fatalf("Failed to create SwissMap: %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		s.Put(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i))
+	}
+
+	if s.Len() != 1000 {
+		t.Errorf("Expected length 1000, got %d", s.Len())
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i)
+		expectedValue := fmt.Sprintf("value%d", i)
+		if value, _ := s.Get(key); value != expectedValue {
+			t.Errorf("Key '%s': expected '%s', got '%s'", key, expectedValue, value)
+		}
+	}
+
+	s.Put("key0", "updated")
+	if value, _ := s.Get("key0"); value != "updated" {
+		t.Errorf("Expected 'updated', got '%s'", value)
+	}
+
+	deleted, _ := s.Delete("key1")
+	if !deleted {
+		t.Error("Expected key1 to be deleted")
+	}
+	if _, ok := s.Lookup("key1"); ok {
+		t.Error("Expected key1 to be absent after delete")
+	}
+	if s.Len() != 999 {
+		t.Errorf("Expected length 999, got %d", s.Len())
+	}
+}
+
+// Test churn that leaves many tombstones behind
+func TestSwissMap_MatchesBuiltinMap(t *testing.T) {
+	s, err := NewSwissMap[int, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create SwissMap: %v", err)
+	}
+
+	expected := map[int]int{}
+
+	for i := 0; i < 20000; i++ {
+		key := (i * 7919) % 500
+		if i%3 == 0 {
+			s.Delete(key)
+			delete(expected, key)
+		} else {
+			s.Put(key, i)
+			expected[key] = i
+		}
+	}
+
+	if s.Len() != len(expected) {
+		t.Errorf("Expected length %d, got %d", len(expected), s.Len())
+	}
+
+	for key := 0; key < 500; key++ {
+		value, ok := s.Lookup(key)
+		expectedValue, expectedOk := expected[key]
+		if ok != expectedOk || value != expectedValue {
+			t.Errorf("Key %d: expected (%d, %v), got (%d, %v)", key, expectedValue, expectedOk, value, ok)
+		}
+	}
+
+	// Tombstone-only rehashes must not grow the table without bound
+	if len(s.groups) > 256 {
+		t.Errorf("Expected at most 256 groups for 500 keys, got %d", len(s.groups))
+	}
+}
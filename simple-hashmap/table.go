@@ -0,0 +1,19 @@
+package main
+
+// Table is the surface shared by the map backends in this package, so
+// callers can switch between them.
+type Table[K comparable, V any] interface {
+	Put(key K, value V) error
+	Get(key K) (V, error)
+	Lookup(key K) (V, bool)
+	Delete(key K) (bool, error)
+	Len() int
+}
+
+var (
+	_ Table[string, string] = (*HashMap[string, string])(nil)
+	_ Table[string, string] = (*SwissMap[string, string])(nil)
+	_ Table[string, string] = (*CuckooMap[string, string])(nil)
+	_ Table[string, string] = (*LinkedHashMap[string, string])(nil)
+	_ Table[string, string] = (*ArenaMap)(nil)
+)
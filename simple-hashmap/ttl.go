@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// Clock is the time source for entry TTLs, so tests can control it.
+type Clock interface {
+	Now() time.Time
+}
+
+func (h *HashMap[K, V]) now() int64 {
+	if h.clock == nil {
+		return time.Now().UnixNano()
+	}
+
+	return h.clock.Now().UnixNano()
+}
+
+// expired only reads the clock for entries that have a TTL.
+func (h *HashMap[K, V]) expired(e *Entry[K, V]) bool {
+	return e.expires != 0 && h.now() >= e.expires
+}
+
+// PutWithTTL stores value for key until ttl has passed. An expired entry
+// is invisible to every read and write, and is reclaimed by the next
+// operation that finds it or by PurgeExpired. Put drops the TTL of an
+// existing key while Compute and Merge keep it. Snapshots leave expired
+// entries out and store the others without a TTL.
+func (h *HashMap[K, V]) PutWithTTL(key K, value V, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("Invalid TTL.")
+	}
+
+	hash, i, found, err := h.findForWrite(key)
+	if err != nil {
+		return err
+	}
+
+	entry := h.list[i]
+	if found {
+		h.setValue(entry, value)
+	} else {
+		entry = h.insertAt(i, key, value, hash)
+	}
+
+	// A TTL reaching past the end of int64 time never expires.
+	now := h.now()
+	entry.expires = now + int64(ttl)
+	if now > 0 && int64(ttl) > math.MaxInt64-now {
+		entry.expires = math.MaxInt64
+	}
+
+	return nil
+}
+
+// reclaim deletes the expired entry for key found by a read.
+func (h *HashMap[K, V]) reclaim(key K, hash uint64) {
+	h.pullForward(key, hash)
+
+	if i, found := h.find(key, hash); found {
+		h.deleteAt(i)
+		h.maybeShrink()
+	}
+}
+
+// PurgeExpired deletes every expired entry and returns how many it found.
+func (h *HashMap[K, V]) PurgeExpired() int {
+	h.finishMigration()
+
+	purged := 0
+	for i := uint64(0); i < h.size; {
+		entry := h.list[i]
+
+		// Backward shift may move a later entry into slot i, so look at it
+		// again after a delete.
+		if entry != nil && entry != h.tomb && h.expired(entry) {
+			h.deleteAt(i)
+			purged++
+			continue
+		}
+
+		i++
+	}
+
+	if purged > 0 {
+		h.maybeShrink()
+	}
+
+	return purged
+}
+
+func (c *ConcurrentHashMap[K, V]) PutWithTTL(key K, value V, ttl time.Duration) error {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.m.PutWithTTL(key, value, ttl)
+}
+
+// PurgeExpired purges one shard at a time, so readers and writers of the
+// other shards are not blocked.
+func (c *ConcurrentHashMap[K, V]) PurgeExpired() int {
+	purged := 0
+
+	for i := range c.shards {
+		s := &c.shards[i]
+
+		s.mu.Lock()
+		purged += s.m.PurgeExpired()
+		s.mu.Unlock()
+	}
+
+	return purged
+}
+
+// StartJanitor purges expired entries in the background every interval.
+// The returned function stops the janitor and waits for it to exit.
+func (c *ConcurrentHashMap[K, V]) StartJanitor(interval time.Duration) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, errors.New("Invalid janitor interval.")
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.PurgeExpired()
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+		<-done
+	}, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when a test advances it.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1_700_000_000, 0)}
+}
+
+// Test that an entry expires after its TTL and is reclaimed on lookup
+func TestHashMap_PutWithTTL(t *testing.T) {
+	clock := newFakeClock()
+	hm, err := NewHashMap(8, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.PutWithTTL("session", "token", time.Minute)
+	hm.Put("forever", "value")
+
+	clock.Advance(59 * time.Second)
+	if value, _ := hm.Get("session"); value != "token" {
+		t.Errorf("Expected token before expiry, got %q", value)
+	}
+
+	clock.Advance(time.Second)
+	if _, ok := hm.Lookup("session"); ok {
+		t.Error("Expected session to be expired")
+	}
+
+	// Lookup reclaimed the entry
+	if hm.Len() != 1 {
+		t.Errorf("Expected 1 entry after lazy reclaim, got %d", hm.Len())
+	}
+
+	if value, _ := hm.Get("forever"); value != "value" {
+		t.Errorf("Expected entry without TTL to stay, got %q", value)
+	}
+}
+
+// Test how Put, Merge and LoadOrStore treat an existing TTL
+func TestHashMap_TTLWrites(t *testing.T) {
+	clock := newFakeClock()
+	hm, err := NewHashMapOf[string, int](8, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	// Put clears the TTL
+	hm.PutWithTTL("put", 1, time.Second)
+	hm.Put("put", 2)
+
+	// Merge keeps it
+	hm.PutWithTTL("merge", 1, time.Second)
+	hm.Merge("merge", 1, func(old, value int) int { return old + value })
+
+	// An expired key is absent to LoadOrStore
+	hm.PutWithTTL("load", 1, time.Second)
+
+	clock.Advance(time.Second)
+
+	if value, ok := hm.Lookup("put"); !ok || value != 2 {
+		t.Errorf("Expected Put to clear the TTL, got (%d, %v)", value, ok)
+	}
+
+	if _, ok := hm.Lookup("merge"); ok {
+		t.Error("Expected Merge to keep the TTL")
+	}
+
+	if actual, loaded, _ := hm.LoadOrStore("load", 5); loaded || actual != 5 {
+		t.Errorf("Expected expired key to be stored again, got (%d, %v)", actual, loaded)
+	}
+
+	if err := hm.PutWithTTL("bad", 1, 0); err == nil {
+		t.Error("Expected error for zero TTL, got nil")
+	}
+}
+
+// Test that PurgeExpired removes exactly the expired entries
+func TestHashMap_PurgeExpired(t *testing.T) {
+	for _, pc := range probings {
+		clock := newFakeClock()
+		hm, err := NewHashMapOf[int, int](8, WithClock(clock), WithProbing(pc.probing))
+		if err != nil {
+			t.Fatalf("Failed to create HashMap: %v", err)
+		}
+
+		const n = 1000
+		for i := 0; i < n; i++ {
+			if i%3 == 0 {
+				hm.Put(i, i)
+			} else {
+				hm.PutWithTTL(i, i, time.Duration(i%3)*time.Second)
+			}
+		}
+
+		clock.Advance(time.Second)
+
+		if purged := hm.PurgeExpired(); purged != n/3 {
+			t.Errorf("%s: expected %d purged, got %d", pc.name, n/3, purged)
+		}
+
+		count := 0
+		for key := range hm.Keys() {
+			if key%3 == 1 {
+				t.Errorf("%s: expected key %d to be purged", pc.name, key)
+			}
+			count++
+		}
+
+		if count != hm.Len() {
+			t.Errorf("%s: iterated %d keys, Len is %d", pc.name, count, hm.Len())
+		}
+
+		for i := 0; i < n; i++ {
+			if _, ok := hm.Lookup(i); ok != (i%3 != 1) {
+				t.Errorf("%s: unexpected presence %v for key %d", pc.name, ok, i)
+			}
+		}
+	}
+}
+
+// Test that snapshots leave out expired entries
+func TestHashMap_TTLSnapshot(t *testing.T) {
+	clock := newFakeClock()
+	hm, err := NewHashMapOf[string, int](8, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		hm.PutWithTTL(fmt.Sprintf("key%d", i), i, time.Duration(i+1)*time.Second)
+	}
+
+	clock.Advance(5 * time.Second)
+
+	data, err := hm.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	restored, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if restored.Len() != 5 {
+		t.Errorf("Expected 5 unexpired entries in snapshot, got %d", restored.Len())
+	}
+}
+
+// steppingClock moves forward on every read.
+type steppingClock struct {
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.now = c.now.Add(time.Millisecond)
+	return c.now
+}
+
+// Test that an entry expiring while the snapshot is written cannot make the
+// header count disagree with the records
+func TestHashMap_TTLSnapshotWhileExpiring(t *testing.T) {
+	hm, err := NewHashMapOf[string, int](8, WithClock(&steppingClock{now: time.Unix(1_700_000_000, 0)}))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.Put("kept", 1)
+	hm.PutWithTTL("expiring", 2, 2*time.Millisecond)
+
+	data, err := hm.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	restored, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+}
+
+// Test that a TTL too large to add to the current time never expires
+func TestHashMap_TTLOverflow(t *testing.T) {
+	clock := newFakeClock()
+	hm, err := NewHashMapOf[string, int](8, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	hm.PutWithTTL("forever", 1, time.Duration(math.MaxInt64))
+
+	if !hm.Contains("forever") {
+		t.Error("Expected an entry with a huge TTL to be present")
+	}
+
+	clock.Advance(100 * 365 * 24 * time.Hour)
+	if !hm.Contains("forever") {
+		t.Error("Expected an entry with a huge TTL not to expire")
+	}
+}
+
+// Test that the janitor purges expired entries until it is stopped
+func TestConcurrentHashMap_Janitor(t *testing.T) {
+	clock := newFakeClock()
+	c, err := NewConcurrentHashMap[int, int](4, 64, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		c.PutWithTTL(i, i, time.Minute)
+	}
+	c.Put(100, 100)
+
+	stop, err := c.StartJanitor(time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to start janitor: %v", err)
+	}
+
+	if c.Contains(0) == false {
+		t.Error("Expected entry to be visible before expiry")
+	}
+
+	clock.Advance(time.Minute)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Len() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected janitor to purge expired entries, %d left", c.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+	stop()
+
+	// A stopped janitor purges nothing
+	c.PutWithTTL(1, 1, time.Second)
+	clock.Advance(time.Second)
+	time.Sleep(10 * time.Millisecond)
+
+	if c.Len() != 2 || c.Contains(1) {
+		t.Errorf("Expected expired entry to stay unreclaimed but hidden, Len %d", c.Len())
+	}
+
+	if _, err := c.StartJanitor(0); err == nil {
+		t.Error("Expected error for zero interval, got nil")
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrTxnDone is returned by a Txn used after Commit or Rollback.
+var ErrTxnDone = errors.New("transaction already committed or rolled back")
+
+// Txn buffers writes to a map until Commit applies them all at once.
+// Reads see the transaction's own writes first and the map's committed
+// state for every other key. There is no conflict detection: keys written
+// by others after Begin are overwritten by Commit. A Txn is not safe for
+// concurrent use.
+//
+// The write set is a PersistentMap, so a savepoint is just the version of
+// it at that point and rolling back costs nothing.
+type Txn[K comparable, V any] struct {
+	base       txnBase[K, V]
+	writes     *PersistentMap[K, txnWrite[V]]
+	savepoints []*PersistentMap[K, txnWrite[V]]
+	done       bool
+}
+
+// Savepoint marks a point in a Txn that RollbackTo can return to.
+// Savepoints nest: rolling back to one discards every later one.
+type Savepoint int
+
+type txnWrite[V any] struct {
+	value   V
+	deleted bool
+}
+
+// txnBase is what a Txn needs from the map it runs against.
+type txnBase[K comparable, V any] interface {
+	checkKey(key K) error
+	Lookup(key K) (V, bool)
+	commit(writes *PersistentMap[K, txnWrite[V]])
+}
+
+// Begin starts a transaction on h. h is not locked, so callers sharing it
+// between goroutines must hold their lock around Commit to make it atomic
+// to readers.
+func (h *HashMap[K, V]) Begin() *Txn[K, V] {
+	return newTxn[K, V](h, h.hasher)
+}
+
+// commit cannot fail halfway: keys were validated when they were written
+// and Put and Delete have no other failure.
+func (h *HashMap[K, V]) commit(writes *PersistentMap[K, txnWrite[V]]) {
+	for key, w := range writes.All() {
+		if w.deleted {
+			h.Delete(key)
+		} else {
+			h.Put(key, w.value)
+		}
+	}
+}
+
+// Begin starts a transaction on c. Commit write-locks every shard the
+// transaction touched, in shard order so concurrent commits cannot
+// deadlock, and applies all writes before releasing any of them.
+func (c *ConcurrentHashMap[K, V]) Begin() *Txn[K, V] {
+	return newTxn[K, V](c, c.hasher)
+}
+
+func (c *ConcurrentHashMap[K, V]) checkKey(key K) error {
+	return c.shardFor(key).m.checkKey(key)
+}
+
+func (c *ConcurrentHashMap[K, V]) commit(writes *PersistentMap[K, txnWrite[V]]) {
+	var touched []uint64
+	for key := range writes.All() {
+		touched = append(touched, c.shardIndex(key))
+	}
+
+	slices.Sort(touched)
+	touched = slices.Compact(touched)
+
+	for _, i := range touched {
+		c.shards[i].mu.Lock()
+	}
+
+	for key, w := range writes.All() {
+		s := &c.shards[c.shardIndex(key)]
+
+		if w.deleted {
+			s.m.Delete(key)
+		} else {
+			s.m.Put(key, w.value)
+		}
+	}
+
+	for _, i := range touched {
+		c.shards[i].mu.Unlock()
+	}
+}
+
+// newTxn builds the write set with the map's hasher, so keys match in the
+// write set exactly when they match in the map.
+func newTxn[K comparable, V any](base txnBase[K, V], hasher Hasher[K]) *Txn[K, V] {
+	writes, _ := NewPersistentMapWithHasher[K, txnWrite[V]](hasher)
+	return &Txn[K, V]{base: base, writes: writes}
+}
+
+func (t *Txn[K, V]) write(key K, w txnWrite[V]) error {
+	if t.done {
+		return ErrTxnDone
+	}
+
+	if err := t.base.checkKey(key); err != nil {
+		return err
+	}
+
+	t.writes = t.writes.Put(key, w)
+	return nil
+}
+
+func (t *Txn[K, V]) Put(key K, value V) error {
+	return t.write(key, txnWrite[V]{value: value})
+}
+
+// Delete removes key within the transaction and reports whether it was
+// visible to it.
+func (t *Txn[K, V]) Delete(key K) (bool, error) {
+	if t.done {
+		return false, ErrTxnDone
+	}
+
+	_, ok := t.Lookup(key)
+	if !ok {
+		return false, t.base.checkKey(key)
+	}
+
+	return true, t.write(key, txnWrite[V]{deleted: true})
+}
+
+func (t *Txn[K, V]) Get(key K) (V, error) {
+	var zero V
+
+	if t.done {
+		return zero, ErrTxnDone
+	}
+
+	if err := t.base.checkKey(key); err != nil {
+		return zero, err
+	}
+
+	value, _ := t.Lookup(key)
+	return value, nil
+}
+
+// Lookup returns the value of key as seen by the transaction. Once it has
+// ended that is the map's own value.
+func (t *Txn[K, V]) Lookup(key K) (V, bool) {
+	if t.done {
+		return t.base.Lookup(key)
+	}
+
+	if w, ok := t.writes.Lookup(key); ok {
+		var zero V
+		if w.deleted {
+			return zero, false
+		}
+
+		return w.value, true
+	}
+
+	return t.base.Lookup(key)
+}
+
+// Savepoint marks the current state of the transaction.
+func (t *Txn[K, V]) Savepoint() Savepoint {
+	t.savepoints = append(t.savepoints, t.writes)
+	return Savepoint(len(t.savepoints) - 1)
+}
+
+// RollbackTo undoes every write made after sp. sp stays valid and can be
+// rolled back to again.
+func (t *Txn[K, V]) RollbackTo(sp Savepoint) error {
+	if t.done {
+		return ErrTxnDone
+	}
+
+	if sp < 0 || int(sp) >= len(t.savepoints) {
+		return errors.New("Invalid savepoint.")
+	}
+
+	t.writes = t.savepoints[sp]
+	clear(t.savepoints[sp+1:])
+	t.savepoints = t.savepoints[:sp+1]
+	return nil
+}
+
+// Commit applies every write to the map and ends the transaction.
+func (t *Txn[K, V]) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+
+	t.done = true
+	t.base.commit(t.writes)
+	return nil
+}
+
+// Rollback discards every write and ends the transaction. Rolling back a
+// finished transaction does nothing.
+func (t *Txn[K, V]) Rollback() {
+	t.done = true
+	t.writes = nil
+	t.savepoints = nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// Test that a transaction sees its own writes and hides them until Commit
+func TestTxn_ReadYourWrites(t *testing.T) {
+	h, err := NewHashMap(8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	h.Put("a", "1")
+	h.Put("b", "2")
+
+	txn := h.Begin()
+	txn.Put("a", "10")
+	txn.Put("c", "3")
+	if ok, _ := txn.Delete("b"); !ok {
+		t.Error("Expected Delete to see the committed key")
+	}
+
+	if v, _ := txn.Get("a"); v != "10" {
+		t.Errorf("Expected own write 10, got %q", v)
+	}
+	if _, ok := txn.Lookup("b"); ok {
+		t.Error("Expected own delete to hide the key")
+	}
+	if ok, _ := txn.Delete("missing"); ok {
+		t.Error("Expected Delete of a missing key to report false")
+	}
+
+	if err := txn.Put("", "x"); err == nil {
+		t.Error("Expected error for an empty key, got nil")
+	}
+
+	// Nothing is visible outside until Commit
+	if v, _ := h.Get("a"); v != "1" || h.Contains("c") || !h.Contains("b") {
+		t.Error("Uncommitted writes leaked into the map")
+	}
+
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	if v, _ := h.Get("a"); v != "10" || h.Contains("b") || !h.Contains("c") || h.Len() != 2 {
+		t.Error("Committed writes were not applied")
+	}
+
+	if err := txn.Put("d", "4"); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone, got %v", err)
+	}
+	if err := txn.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone on second Commit, got %v", err)
+	}
+}
+
+// Test that Rollback discards every write and ends the transaction
+func TestTxn_Rollback(t *testing.T) {
+	h, err := NewHashMap(8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	h.Put("a", "1")
+
+	txn := h.Begin()
+	txn.Put("a", "10")
+	txn.Delete("a")
+	txn.Put("b", "2")
+	txn.Rollback()
+
+	if v, _ := h.Get("a"); v != "1" || h.Len() != 1 {
+		t.Error("Rollback changed the map")
+	}
+
+	if _, err := txn.Get("a"); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone, got %v", err)
+	}
+}
+
+// Test rolling back to nested savepoints
+func TestTxn_Savepoints(t *testing.T) {
+	h, err := NewHashMap(8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	h.Put("a", "1")
+
+	txn := h.Begin()
+	txn.Put("a", "2")
+	outer := txn.Savepoint()
+
+	txn.Put("a", "3")
+	txn.Put("b", "b")
+	inner := txn.Savepoint()
+
+	txn.Delete("a")
+	txn.Put("c", "c")
+
+	if err := txn.RollbackTo(inner); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if v, _ := txn.Get("a"); v != "3" {
+		t.Errorf("Expected 3 after inner rollback, got %q", v)
+	}
+	if _, ok := txn.Lookup("c"); ok {
+		t.Error("Expected c to be rolled back")
+	}
+
+	if err := txn.RollbackTo(outer); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if v, _ := txn.Get("a"); v != "2" {
+		t.Errorf("Expected 2 after outer rollback, got %q", v)
+	}
+	if _, ok := txn.Lookup("b"); ok {
+		t.Error("Expected b to be rolled back")
+	}
+
+	// The inner savepoint was discarded with everything after outer
+	if err := txn.RollbackTo(inner); err == nil {
+		t.Error("Expected error for a discarded savepoint, got nil")
+	}
+
+	txn.Commit()
+
+	if v, _ := h.Get("a"); v != "2" || h.Len() != 1 {
+		t.Error("Expected only the writes before outer to be committed")
+	}
+}
+
+// Test that Len, which locks every shard, sees all of a commit or none of
+// it, run with -race
+func TestTxn_ConcurrentCommitIsAtomic(t *testing.T) {
+	c, err := NewConcurrentHashMap[int, int](8, 64)
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	const batch = 50
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 0; round < 20; round++ {
+			txn := c.Begin()
+			for i := 0; i < batch; i++ {
+				txn.Put(round*batch+i, i)
+			}
+			txn.Commit()
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		if n := c.Len(); n%batch != 0 {
+			t.Fatalf("Observed a partial commit: length %d", n)
+		}
+	}
+	wg.Wait()
+
+	if c.Len() != 20*batch {
+		t.Errorf("Expected length %d, got %d", 20*batch, c.Len())
+	}
+
+	txn := c.Begin()
+	txn.Put(0, -1)
+	txn.Delete(1)
+	if v, _ := txn.Get(0); v != -1 {
+		t.Errorf("Expected own write -1, got %d", v)
+	}
+	txn.Commit()
+
+	if v, _ := c.Get(0); v != -1 || c.Contains(1) {
+		t.Error("Committed writes were not applied")
+	}
+}
@@ -0,0 +1,659 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DurableHashMap is a HashMap backed by an append-only write-ahead log.
+// Every Put and Delete is appended to the log before it is applied, and
+// opening the log replays it to rebuild the map. It is safe for use by
+// multiple goroutines.
+//
+// Log format, all integers little endian:
+//
+//	magic    "HWAL"
+//	version  uint8
+//	records of
+//	  length  uint32, size of the payload, at most 64 MiB
+//	  hcrc    uint32, IEEE checksum of the length
+//	  crc32   uint32, IEEE checksum of the payload
+//	  payload op byte, then key and, for puts, value as in snapshots
+//
+// A crash can leave a partially written record at the end of the log. On
+// open a last record that is short or fails its payload checksum is
+// treated as such a torn tail, and the log is truncated right before it,
+// as is a tail of zeros the file system may leave behind. A length that
+// fails its checksum or any other bad record is corruption and fails the
+// open with ErrInvalidLog, so replay never cuts off more than one record.
+// A failed append is cut off again right away, so it never ends up in
+// front of later records.
+type DurableHashMap[K comparable, V any] struct {
+	mu      sync.Mutex
+	m       *HashMap[K, V]
+	f       walFile
+	path    string
+	opts    durableOptions
+	size    int64  // end of the last good record
+	records uint64 // records in the log, including superseded ones
+	dirty   bool   // appended since the last fsync
+	failed  error  // set when a failed append could not be cut off
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// walFile is the part of *os.File the log uses.
+type walFile interface {
+	io.ReadWriteSeeker
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+	Stat() (os.FileInfo, error)
+}
+
+// SyncPolicy controls when appended records are fsynced.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs after every record, so an acknowledged write
+	// survives a power loss.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs from a background goroutine at a fixed interval,
+	// bounding how much a power loss can take.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+const (
+	walMagic   = "HWAL"
+	walVersion = 1
+
+	walOpPut    = 1
+	walOpDelete = 2
+
+	walRecordHeader = 12
+	walMaxRecord    = 64 << 20
+)
+
+var ErrInvalidLog = errors.New("invalid HashMap log")
+
+type durableOptions struct {
+	sync            SyncPolicy
+	syncInterval    time.Duration
+	compactInterval time.Duration
+	mapOpts         []Option
+}
+
+// DurableOption configures a DurableHashMap.
+type DurableOption func(*durableOptions) error
+
+func WithSyncPolicy(policy SyncPolicy) DurableOption {
+	return func(o *durableOptions) error {
+		if policy != SyncAlways && policy != SyncInterval && policy != SyncNever {
+			return errors.New("Invalid sync policy.")
+		}
+
+		o.sync = policy
+		return nil
+	}
+}
+
+// WithSyncInterval sets how often SyncInterval fsyncs. The default is one
+// second.
+func WithSyncInterval(interval time.Duration) DurableOption {
+	return func(o *durableOptions) error {
+		if interval <= 0 {
+			return errors.New("Invalid sync interval.")
+		}
+
+		o.syncInterval = interval
+		return nil
+	}
+}
+
+// WithCompactInterval compacts the log in the background at a fixed
+// interval whenever it holds superseded records.
+func WithCompactInterval(interval time.Duration) DurableOption {
+	return func(o *durableOptions) error {
+		if interval <= 0 {
+			return errors.New("Invalid compaction interval.")
+		}
+
+		o.compactInterval = interval
+		return nil
+	}
+}
+
+// WithMapOptions configures the in-memory HashMap.
+func WithMapOptions(opts ...Option) DurableOption {
+	return func(o *durableOptions) error {
+		o.mapOpts = append(o.mapOpts, opts...)
+		return nil
+	}
+}
+
+// OpenDurableHashMap opens or creates the log at path and replays it.
+func OpenDurableHashMap[K comparable, V any](path string, opts ...DurableOption) (*DurableHashMap[K, V], error) {
+	o := durableOptions{sync: SyncAlways, syncInterval: time.Second}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := NewHashMapOf[K, V](16, o.mapOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DurableHashMap[K, V]{m: m, f: f, path: path, opts: o}
+
+	if err := d.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if o.sync == SyncInterval || o.compactInterval > 0 {
+		d.stop = make(chan struct{})
+		d.done = make(chan struct{})
+		go d.background()
+	}
+
+	return d, nil
+}
+
+// replay rebuilds the map from the log and leaves the file positioned for
+// appending after the last good record.
+func (d *DurableHashMap[K, V]) replay() error {
+	info, err := d.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() == 0 {
+		d.size = int64(len(walMagic) + 1)
+		return d.writeHeader(d.f)
+	}
+
+	r := bufio.NewReader(d.f)
+
+	header := make([]byte, len(walMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("%w: short header", ErrInvalidLog)
+	}
+
+	if string(header[:len(walMagic)]) != walMagic {
+		return fmt.Errorf("%w: bad magic", ErrInvalidLog)
+	}
+
+	if version := header[len(walMagic)]; version != walVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidLog, version)
+	}
+
+	offset := int64(len(header))
+	recordHeader := make([]byte, walRecordHeader)
+	var payload []byte
+
+	for {
+		if _, err := io.ReadFull(r, recordHeader); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+
+			return err
+		}
+
+		length := binary.LittleEndian.Uint32(recordHeader)
+		if crc32.ChecksumIEEE(recordHeader[:4]) != binary.LittleEndian.Uint32(recordHeader[4:]) {
+			if zeros, err := onlyZeros(r); err != nil || !zeros {
+				return fmt.Errorf("%w: bad length in record at offset %d", ErrInvalidLog, offset)
+			}
+
+			break
+		}
+
+		if length > walMaxRecord {
+			return fmt.Errorf("%w: record at offset %d is too large", ErrInvalidLog, offset)
+		}
+
+		sum := binary.LittleEndian.Uint32(recordHeader[8:])
+
+		// The length is intact, so a record running past the end was torn
+		// while its payload was written.
+		end := offset + walRecordHeader + int64(length)
+		if end > info.Size() {
+			break
+		}
+
+		payload = append(payload[:0], make([]byte, length)...)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+
+		if crc32.ChecksumIEEE(payload) != sum {
+			if end < info.Size() {
+				return fmt.Errorf("%w: bad checksum in record at offset %d", ErrInvalidLog, offset)
+			}
+
+			break
+		}
+
+		if err := d.apply(payload); err != nil {
+			return fmt.Errorf("%w: record at offset %d: %v", ErrInvalidLog, offset, err)
+		}
+
+		offset += walRecordHeader + int64(length)
+		d.records++
+	}
+
+	// Anything after the last good record is a torn write, which is never
+	// more than one record.
+	if info.Size()-offset > walRecordHeader+walMaxRecord {
+		return fmt.Errorf("%w: %d bytes after the record at offset %d", ErrInvalidLog, info.Size()-offset, offset)
+	}
+
+	if offset < info.Size() {
+		if err := d.f.Truncate(offset); err != nil {
+			return err
+		}
+	}
+
+	d.size = offset
+	_, err = d.f.Seek(offset, io.SeekStart)
+	return err
+}
+
+// onlyZeros reports whether the rest of r is zero bytes.
+func onlyZeros(r *bufio.Reader) (bool, error) {
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return true, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if b != 0 {
+			return false, nil
+		}
+	}
+}
+
+func (d *DurableHashMap[K, V]) apply(payload []byte) error {
+	if len(payload) == 0 {
+		return errors.New("empty record")
+	}
+
+	r := &snapshotReader{r: bufio.NewReader(bytes.NewReader(payload[1:])), crc: crc32.NewIEEE()}
+
+	var key K
+	if _, err := r.readField(nil, &key); err != nil {
+		return err
+	}
+
+	switch payload[0] {
+	case walOpPut:
+		var value V
+		if _, err := r.readField(nil, &value); err != nil {
+			return err
+		}
+
+		return d.m.Put(key, value)
+	case walOpDelete:
+		_, err := d.m.Delete(key)
+		return err
+	}
+
+	return fmt.Errorf("unknown op %d", payload[0])
+}
+
+func (d *DurableHashMap[K, V]) writeHeader(w io.Writer) error {
+	_, err := w.Write(append([]byte(walMagic), walVersion))
+	return err
+}
+
+// encodeRecord frames an operation with its length and checksum.
+func encodeRecord(buf []byte, op byte, key, value any) ([]byte, error) {
+	buf = append(buf[:0], make([]byte, walRecordHeader)...)
+	buf = append(buf, op)
+
+	buf, err := appendField(buf, key)
+	if err != nil {
+		return buf, fmt.Errorf("encoding key: %w", err)
+	}
+
+	if op == walOpPut {
+		if buf, err = appendField(buf, value); err != nil {
+			return buf, fmt.Errorf("encoding value: %w", err)
+		}
+	}
+
+	payload := buf[walRecordHeader:]
+	if len(payload) > walMaxRecord {
+		return buf, fmt.Errorf("record of %d bytes exceeds the %d byte limit", len(payload), walMaxRecord)
+	}
+
+	binary.LittleEndian.PutUint32(buf, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(buf[:4]))
+	binary.LittleEndian.PutUint32(buf[8:], crc32.ChecksumIEEE(payload))
+	return buf, nil
+}
+
+// appendRecord writes a record and fsyncs according to the policy. The
+// caller holds d.mu.
+func (d *DurableHashMap[K, V]) appendRecord(op byte, key K, value V) error {
+	if d.f == nil {
+		return os.ErrClosed
+	}
+
+	if d.failed != nil {
+		return d.failed
+	}
+
+	record, err := encodeRecord(nil, op, key, value)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.f.Write(record); err != nil {
+		d.cutOff()
+		return err
+	}
+
+	d.dirty = true
+
+	// A record that did not reach the disk is not acknowledged, so it must
+	// not replay either.
+	if d.opts.sync == SyncAlways {
+		if err := d.sync(); err != nil {
+			d.cutOff()
+			return err
+		}
+	}
+
+	d.size += int64(len(record))
+	d.records++
+	return nil
+}
+
+// cutOff removes whatever a failed append left after the last good record.
+// If that fails too the log refuses further appends, since they would
+// follow bytes that replay stops at.
+func (d *DurableHashMap[K, V]) cutOff() {
+	err := d.f.Truncate(d.size)
+	if err == nil {
+		_, err = d.f.Seek(d.size, io.SeekStart)
+	}
+
+	if err != nil {
+		d.failed = fmt.Errorf("log unusable after failed append: %w", err)
+	}
+}
+
+func (d *DurableHashMap[K, V]) sync() error {
+	if !d.dirty {
+		return nil
+	}
+
+	if err := d.f.Sync(); err != nil {
+		return err
+	}
+
+	d.dirty = false
+	return nil
+}
+
+func (d *DurableHashMap[K, V]) Put(key K, value V) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.m.checkKey(key); err != nil {
+		return err
+	}
+
+	if err := d.appendRecord(walOpPut, key, value); err != nil {
+		return err
+	}
+
+	return d.m.Put(key, value)
+}
+
+func (d *DurableHashMap[K, V]) Get(key K) (V, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.m.Get(key)
+}
+
+func (d *DurableHashMap[K, V]) Lookup(key K) (V, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.m.Lookup(key)
+}
+
+// Delete logs and removes key. Deleting a missing key writes nothing.
+func (d *DurableHashMap[K, V]) Delete(key K) (bool, error) {
+	var zero V
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.m.checkKey(key); err != nil {
+		return false, err
+	}
+
+	if !d.m.Contains(key) {
+		return false, nil
+	}
+
+	if err := d.appendRecord(walOpDelete, key, zero); err != nil {
+		return false, err
+	}
+
+	return d.m.Delete(key)
+}
+
+func (d *DurableHashMap[K, V]) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.m.Len()
+}
+
+// All iterates over a copy of the entries, so the loop body may use the map.
+func (d *DurableHashMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		d.mu.Lock()
+		entries := make([]Entry[K, V], 0, d.m.Len())
+		for entry := range d.m.entries() {
+			entries = append(entries, *entry)
+		}
+		d.mu.Unlock()
+
+		for _, entry := range entries {
+			if !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+// Sync fsyncs any records not yet on stable storage.
+func (d *DurableHashMap[K, V]) Sync() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.f == nil {
+		return os.ErrClosed
+	}
+
+	return d.sync()
+}
+
+// Compact rewrites the log with one put per live entry, dropping
+// overwritten and deleted records. The new log is written next to the old
+// one and renamed over it, so a crash leaves one of the two intact.
+func (d *DurableHashMap[K, V]) Compact() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.compact()
+}
+
+func (d *DurableHashMap[K, V]) compact() error {
+	if d.f == nil {
+		return os.ErrClosed
+	}
+
+	tmpPath := d.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	size, err := d.writeSnapshotLog(tmp)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, d.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// The new log is at d.path from here on, so appends must go to it even
+	// if making the rename durable fails.
+	d.f.Close()
+	d.f = tmp
+	d.size = size
+	d.records = uint64(d.m.Len())
+	d.dirty = false
+
+	// The new log holds exactly the map, so it is good again.
+	d.failed = nil
+	return syncDir(filepath.Dir(d.path))
+}
+
+// writeSnapshotLog writes a log holding one put per live entry and returns
+// its size.
+func (d *DurableHashMap[K, V]) writeSnapshotLog(f *os.File) (int64, error) {
+	w := bufio.NewWriter(f)
+
+	if err := d.writeHeader(w); err != nil {
+		return 0, err
+	}
+
+	size := int64(len(walMagic) + 1)
+	var record []byte
+	var err error
+
+	for entry := range d.m.entries() {
+		if record, err = encodeRecord(record, walOpPut, entry.key, entry.value); err != nil {
+			return 0, err
+		}
+
+		if _, err := w.Write(record); err != nil {
+			return 0, err
+		}
+
+		size += int64(len(record))
+	}
+
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+
+	return size, f.Sync()
+}
+
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// background runs interval fsyncs and compactions until Close.
+func (d *DurableHashMap[K, V]) background() {
+	defer close(d.done)
+
+	var syncTick, compactTick <-chan time.Time
+
+	if d.opts.sync == SyncInterval {
+		ticker := time.NewTicker(d.opts.syncInterval)
+		defer ticker.Stop()
+		syncTick = ticker.C
+	}
+
+	if d.opts.compactInterval > 0 {
+		ticker := time.NewTicker(d.opts.compactInterval)
+		defer ticker.Stop()
+		compactTick = ticker.C
+	}
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-syncTick:
+			d.mu.Lock()
+			d.sync()
+			d.mu.Unlock()
+		case <-compactTick:
+			d.mu.Lock()
+			if d.records > uint64(d.m.Len()) {
+				d.compact()
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+// Close stops background work, fsyncs the log and closes it.
+func (d *DurableHashMap[K, V]) Close() error {
+	if d.stop != nil {
+		close(d.stop)
+		<-d.done
+		d.stop = nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.f == nil {
+		return os.ErrClosed
+	}
+
+	err := d.sync()
+	if closeErr := d.f.Close(); err == nil {
+		err = closeErr
+	}
+
+	d.f = nil
+	return err
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test that puts, overwrites and deletes survive a reopen
+func TestDurableHashMap_ReplayAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	d, err := OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := d.Put(fmt.Sprintf("key%d", i), i); err != nil {
+			t.Fatalf("Failed to put key%d: %v", i, err)
+		}
+	}
+	d.Put("key0", -1)
+	d.Delete("key1")
+	d.Close()
+
+	d, err = OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer d.Close()
+
+	if d.Len() != 99 {
+		t.Errorf("Expected 99 entries after replay, got %d", d.Len())
+	}
+
+	if value, _ := d.Get("key0"); value != -1 {
+		t.Errorf("Expected overwritten value -1, got %d", value)
+	}
+
+	if _, ok := d.Lookup("key1"); ok {
+		t.Error("Expected deleted key to stay deleted after replay")
+	}
+
+	if value, _ := d.Get("key99"); value != 99 {
+		t.Errorf("Expected 99, got %d", value)
+	}
+}
+
+// Test that a torn last record is truncated and later appends replay
+func TestDurableHashMap_TornTail(t *testing.T) {
+	// Tearing the last record loses "second", tearing after it loses nothing
+	testCases := []struct {
+		name        string
+		tear        func(data []byte) []byte
+		expectedLen int
+	}{
+		{"partial header", func(data []byte) []byte { return append(data, 7, 0, 0) }, 2},
+		{"zero fill", func(data []byte) []byte { return append(data, make([]byte, 100)...) }, 2},
+		{"partial payload", func(data []byte) []byte { return data[:len(data)-3] }, 1},
+		{"bad checksum", func(data []byte) []byte { data[len(data)-1] ^= 0xFF; return data }, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "map.wal")
+
+			d, err := OpenDurableHashMap[string, int](path)
+			if err != nil {
+				t.Fatalf("Failed to open log: %v", err)
+			}
+
+			d.Put("first", 1)
+			d.Put("second", 2)
+			d.Close()
+
+			intact, _ := os.ReadFile(path)
+			os.WriteFile(path, tc.tear(append([]byte(nil), intact...)), 0o644)
+
+			d, err = OpenDurableHashMap[string, int](path)
+			if err != nil {
+				t.Fatalf("Failed to open log: %v", err)
+			}
+
+			if d.Len() != tc.expectedLen {
+				t.Errorf("Expected %d entries after recovery, got %d", tc.expectedLen, d.Len())
+			}
+			if value, _ := d.Get("first"); value != 1 {
+				t.Errorf("Expected first record to survive, got %d", value)
+			}
+
+			// The torn bytes are gone, so new records follow the good ones
+			d.Put("third", 3)
+			d.Close()
+
+			d, err = OpenDurableHashMap[string, int](path)
+			if err != nil {
+				t.Fatalf("Failed to open log: %v", err)
+			}
+			defer d.Close()
+
+			if value, ok := d.Lookup("third"); !ok || value != 3 {
+				t.Errorf("Expected record appended after recovery to replay, got (%d, %v)", value, ok)
+			}
+		})
+	}
+}
+
+// Test that a file that is not a log is rejected
+func TestDurableHashMap_InvalidLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+	os.WriteFile(path, []byte("not a log at all"), 0o644)
+
+	if _, err := OpenDurableHashMap[string, int](path); err == nil {
+		t.Error("Expected error for a file that is not a log, got nil")
+	}
+}
+
+// Test that a bad record followed by good ones is reported, not truncated
+func TestDurableHashMap_CorruptMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	d, err := OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	d.Put("first", 1)
+	d.Put("second", 2)
+	d.Close()
+
+	data, _ := os.ReadFile(path)
+	data[len(walMagic)+1+walRecordHeader+2] ^= 0xFF
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := OpenDurableHashMap[string, int](path); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("Expected ErrInvalidLog, got %v", err)
+	}
+}
+
+// failingFile writes only part of the record after fail is set
+// Test that a damaged length is reported instead of truncating the log at
+// the record it points past
+func TestDurableHashMap_CorruptLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	d, err := OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		d.Put(fmt.Sprintf("key%d", i), i)
+	}
+	d.Close()
+
+	data, _ := os.ReadFile(path)
+	data[len(walMagic)+1+3] ^= 0x01
+	os.WriteFile(path, data, 0o644)
+
+	if _, err := OpenDurableHashMap[string, int](path); !errors.Is(err, ErrInvalidLog) {
+		t.Errorf("Expected ErrInvalidLog, got %v", err)
+	}
+
+	if info, _ := os.Stat(path); info.Size() != int64(len(data)) {
+		t.Errorf("Expected the log to keep its %d bytes, got %d", len(data), info.Size())
+	}
+}
+
+type failingFile struct {
+	*os.File
+	fail bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if f.fail {
+		n, _ := f.File.Write(p[:len(p)/2])
+		return n, errors.New("no space left on device")
+	}
+
+	return f.File.Write(p)
+}
+
+// Test that a failed append does not hide the records written after it
+func TestDurableHashMap_FailedAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	d, err := OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+
+	d.Put("first", 1)
+
+	f := &failingFile{File: d.f.(*os.File)}
+	d.f = f
+	f.fail = true
+
+	if err := d.Put("lost", 2); err == nil {
+		t.Fatal("Expected the failed write to be reported, got nil")
+	}
+	if _, ok := d.Lookup("lost"); ok {
+		t.Error("Expected a failed Put to leave the map unchanged")
+	}
+
+	f.fail = false
+	d.Put("after", 3)
+	d.Close()
+
+	d, err = OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to reopen log: %v", err)
+	}
+	defer d.Close()
+
+	if _, ok := d.Lookup("after"); !ok || d.Len() != 2 {
+		t.Errorf("Expected first and after to replay, got %d entries", d.Len())
+	}
+}
+
+// Test that compaction drops superseded records and keeps the live ones
+func TestDurableHashMap_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	d, err := OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+
+	for round := 0; round < 10; round++ {
+		for i := 0; i < 100; i++ {
+			d.Put(fmt.Sprintf("key%d", i), round)
+		}
+	}
+	for i := 50; i < 100; i++ {
+		d.Delete(fmt.Sprintf("key%d", i))
+	}
+
+	before, _ := os.Stat(path)
+	if err := d.Compact(); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	after, _ := os.Stat(path)
+
+	if after.Size() >= before.Size()/10 {
+		t.Errorf("Expected compaction to shrink the log from %d bytes, got %d", before.Size(), after.Size())
+	}
+
+	// Writes after compaction go to the new log
+	d.Put("late", 42)
+	d.Close()
+
+	d, err = OpenDurableHashMap[string, int](path)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	defer d.Close()
+
+	if d.Len() != 51 {
+		t.Errorf("Expected 51 entries after compaction, got %d", d.Len())
+	}
+	if value, _ := d.Get("key0"); value != 9 {
+		t.Errorf("Expected latest value 9, got %d", value)
+	}
+	if value, _ := d.Get("late"); value != 42 {
+		t.Errorf("Expected 42, got %d", value)
+	}
+}
+
+// Test interval fsyncs and background compaction, run with -race
+func TestDurableHashMap_BackgroundWork(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	d, err := OpenDurableHashMap[string, int](path,
+		WithSyncPolicy(SyncInterval),
+		WithSyncInterval(time.Millisecond),
+		WithCompactInterval(5*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		d.Put("key", i)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.mu.Lock()
+		records, dirty := d.records, d.dirty
+		d.mu.Unlock()
+
+		if records == 1 && !dirty {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected background sync and compaction, log has %d records, dirty=%v", records, dirty)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	if err := d.Put("key", 1); err == nil {
+		t.Error("Expected error writing to a closed map, got nil")
+	}
+}
+
+// Test that invalid options are rejected
+func TestDurableHashMap_InvalidOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.wal")
+
+	options := []DurableOption{
+		WithSyncPolicy(SyncPolicy(9)),
+		WithSyncInterval(0),
+		WithCompactInterval(-time.Second),
+		WithMapOptions(WithLoadFactor(3)),
+	}
+
+	for _, opt := range options {
+		if _, err := OpenDurableHashMap[string, int](path, opt); err == nil {
+			t.Error("Expected error for invalid option, got nil")
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind says what changed in a map.
+type EventKind int
+
+const (
+	// EventPut is sent when a key is added or its value replaced.
+	EventPut EventKind = iota
+	// EventDelete is sent when a key is removed, including when an
+	// expired entry is reclaimed.
+	EventDelete
+	// EventRehash is sent when the table is rebuilt. It carries no key.
+	EventRehash
+)
+
+// Event describes one change to a map.
+type Event[K comparable, V any] struct {
+	Kind EventKind
+	Key  K
+
+	// Old is the value before a replacing put or a delete, and HasOld says
+	// whether there was one. New is the value stored by a put.
+	Old    V
+	HasOld bool
+	New    V
+
+	// Capacity is the new table size of a rehash.
+	Capacity uint64
+}
+
+// watchers is the subscriber list of a map. It is copied on write, so
+// sending an event takes no lock and a callback may cancel itself.
+type watchers[K comparable, V any] struct {
+	mu   sync.Mutex // serializes changes to subs
+	subs atomic.Pointer[[]*subscriber[K, V]]
+}
+
+type subscriber[K comparable, V any] struct {
+	prefix    string
+	hasPrefix bool
+	fn        func(Event[K, V]) // set for OnChange
+	w         *Watcher[K, V]    // set for Watch
+}
+
+// Watcher receives the events of a map on C. By default a full buffer
+// drops the event and counts it instead of blocking the writer.
+type Watcher[K comparable, V any] struct {
+	C <-chan Event[K, V]
+
+	ch       chan Event[K, V]
+	blocking bool
+	dropped  atomic.Uint64
+	parent   *watchers[K, V]
+	sub      *subscriber[K, V]
+
+	// mu is held while sending so that Close cannot close ch under a
+	// sender; done releases a sender blocked on a full channel.
+	mu        sync.Mutex
+	closed    bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type watchOptions struct {
+	prefix    string
+	hasPrefix bool
+	buffer    int
+	blocking  bool
+}
+
+// WatchOption configures a subscription made with Watch or OnChange.
+type WatchOption func(*watchOptions) error
+
+// WithWatchPrefix only delivers events for keys whose text starts with
+// prefix. String keys are their own text; integers are written in decimal
+// and other keys need encoding.TextMarshaler, or they never match. Rehash
+// events are always delivered.
+func WithWatchPrefix(prefix string) WatchOption {
+	return func(o *watchOptions) error {
+		o.prefix = prefix
+		o.hasPrefix = true
+		return nil
+	}
+}
+
+// WithWatchBuffer sets the channel capacity of a Watcher. The default is 64.
+func WithWatchBuffer(n int) WatchOption {
+	return func(o *watchOptions) error {
+		if n < 0 {
+			return errors.New("Invalid buffer size.")
+		}
+
+		o.buffer = n
+		return nil
+	}
+}
+
+// WithWatchBlocking makes a full Watcher block the writer until there is
+// room, so no event is lost. A consumer that stops reading then stalls
+// every write to the map until it calls Close.
+func WithWatchBlocking() WatchOption {
+	return func(o *watchOptions) error {
+		o.blocking = true
+		return nil
+	}
+}
+
+func newWatchOptions(opts []WatchOption) (watchOptions, error) {
+	o := watchOptions{buffer: 64}
+
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+// Watch subscribes to the changes of h. Events arrive in the order the
+// changes happened. Loading a snapshot with ReadFrom sends a delete for
+// every old key and a put for every new one.
+func (h *HashMap[K, V]) Watch(opts ...WatchOption) (*Watcher[K, V], error) {
+	if h.watch == nil {
+		h.watch = &watchers[K, V]{}
+	}
+
+	return h.watch.watch(opts)
+}
+
+// OnChange calls fn for every change to h until cancel is called. fn runs
+// inside the write that caused the event, so it must be quick and must not
+// use the map; use Watch for slow consumers.
+func (h *HashMap[K, V]) OnChange(fn func(Event[K, V]), opts ...WatchOption) (cancel func(), err error) {
+	if h.watch == nil {
+		h.watch = &watchers[K, V]{}
+	}
+
+	return h.watch.onChange(fn, opts)
+}
+
+// Watch subscribes to the changes of every shard. Events of one key arrive
+// in order; events of keys in different shards may interleave.
+func (c *ConcurrentHashMap[K, V]) Watch(opts ...WatchOption) (*Watcher[K, V], error) {
+	return c.watch.watch(opts)
+}
+
+// OnChange is like HashMap.OnChange. fn runs under the shard's write lock.
+func (c *ConcurrentHashMap[K, V]) OnChange(fn func(Event[K, V]), opts ...WatchOption) (cancel func(), err error) {
+	return c.watch.onChange(fn, opts)
+}
+
+func (ws *watchers[K, V]) watch(opts []WatchOption) (*Watcher[K, V], error) {
+	o, err := newWatchOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Event[K, V], o.buffer)
+	w := &Watcher[K, V]{
+		C:        ch,
+		ch:       ch,
+		blocking: o.blocking,
+		parent:   ws,
+		done:     make(chan struct{}),
+	}
+
+	w.sub = &subscriber[K, V]{prefix: o.prefix, hasPrefix: o.hasPrefix, w: w}
+	ws.add(w.sub)
+	return w, nil
+}
+
+func (ws *watchers[K, V]) onChange(fn func(Event[K, V]), opts []WatchOption) (func(), error) {
+	if fn == nil {
+		return nil, errors.New("Invalid callback.")
+	}
+
+	o, err := newWatchOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &subscriber[K, V]{prefix: o.prefix, hasPrefix: o.hasPrefix, fn: fn}
+	ws.add(sub)
+
+	var once sync.Once
+	return func() { once.Do(func() { ws.remove(sub) }) }, nil
+}
+
+func (ws *watchers[K, V]) add(sub *subscriber[K, V]) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	var subs []*subscriber[K, V]
+	if p := ws.subs.Load(); p != nil {
+		subs = slices.Clone(*p)
+	}
+
+	subs = append(subs, sub)
+	ws.subs.Store(&subs)
+}
+
+func (ws *watchers[K, V]) remove(sub *subscriber[K, V]) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	subs := slices.DeleteFunc(slices.Clone(*ws.subs.Load()), func(s *subscriber[K, V]) bool {
+		return s == sub
+	})
+	ws.subs.Store(&subs)
+}
+
+func (ws *watchers[K, V]) notify(ev Event[K, V]) {
+	p := ws.subs.Load()
+	if p == nil {
+		return
+	}
+
+	var text string
+	var textOK, textDone bool
+
+	for _, sub := range *p {
+		if sub.hasPrefix && ev.Kind != EventRehash {
+			if !textDone {
+				text, textOK = keyText(ev.Key)
+				textDone = true
+			}
+
+			if !textOK || !strings.HasPrefix(text, sub.prefix) {
+				continue
+			}
+		}
+
+		if sub.fn != nil {
+			sub.fn(ev)
+		} else {
+			sub.w.send(ev)
+		}
+	}
+}
+
+// keyText is the text a prefix filter matches against.
+func keyText(key any) (string, bool) {
+	if s, ok := key.(string); ok {
+		return s, true
+	}
+
+	text, err := jsonKey(key)
+	return text, err == nil
+}
+
+func (w *Watcher[K, V]) send(ev Event[K, V]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	if !w.blocking {
+		select {
+		case w.ch <- ev:
+		default:
+			w.dropped.Add(1)
+		}
+
+		return
+	}
+
+	select {
+	case w.ch <- ev:
+	case <-w.done:
+	}
+}
+
+// Dropped returns how many events did not fit in the buffer.
+func (w *Watcher[K, V]) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Close unsubscribes w and closes C. Events already buffered can still be
+// read. It is safe to call from any goroutine and more than once.
+func (w *Watcher[K, V]) Close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		w.parent.remove(w.sub)
+
+		w.mu.Lock()
+		w.closed = true
+		close(w.ch)
+		w.mu.Unlock()
+	})
+}
+
+// notify sends ev to the subscribers of h, if it has any.
+func (h *HashMap[K, V]) notify(ev Event[K, V]) {
+	if h.watch != nil {
+		h.watch.notify(ev)
+	}
+}
+
+// setValue replaces the value of an existing entry.
+func (h *HashMap[K, V]) setValue(e *Entry[K, V], value V) {
+	old := e.value
+	e.value = value
+	h.notify(Event[K, V]{Kind: EventPut, Key: e.key, Old: old, HasOld: true, New: value})
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Test the events OnChange sees for puts, merges and deletes
+func TestOnChange_Events(t *testing.T) {
+	h, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	var events []Event[string, int]
+	cancel, err := h.OnChange(func(ev Event[string, int]) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	h.Put("a", 1)
+	h.Put("a", 2)
+	h.Merge("a", 3, func(old, value int) int { return old + value })
+	h.Delete("a")
+	h.Delete("a")
+
+	want := []Event[string, int]{
+		{Kind: EventPut, Key: "a", New: 1},
+		{Kind: EventPut, Key: "a", Old: 1, HasOld: true, New: 2},
+		{Kind: EventPut, Key: "a", Old: 2, HasOld: true, New: 5},
+		{Kind: EventDelete, Key: "a", Old: 5, HasOld: true},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %v", len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want[i], events[i])
+		}
+	}
+
+	cancel()
+	h.Put("b", 1)
+
+	if len(events) != len(want) {
+		t.Error("Expected no events after cancel")
+	}
+}
+
+// Test rehash events and delete events for reclaimed expired keys
+func TestOnChange_RehashAndExpiry(t *testing.T) {
+	clock := newFakeClock()
+
+	h, err := NewHashMapOf[string, int](4, WithClock(clock))
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	counts := map[EventKind]int{}
+	var capacity uint64
+	h.OnChange(func(ev Event[string, int]) {
+		counts[ev.Kind]++
+		if ev.Kind == EventRehash {
+			capacity = ev.Capacity
+		}
+	})
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		h.Put(key, 0)
+	}
+
+	if counts[EventRehash] == 0 || capacity != h.size {
+		t.Errorf("Expected a rehash event to capacity %d, got %d events to %d", h.size, counts[EventRehash], capacity)
+	}
+
+	h.PutWithTTL("e", 0, time.Second)
+	clock.Advance(2 * time.Second)
+	h.PurgeExpired()
+
+	if counts[EventDelete] != 1 {
+		t.Errorf("Expected a delete event for the expired key, got %d", counts[EventDelete])
+	}
+}
+
+// Test that a prefix filter only delivers matching keys
+func TestWatch_Prefix(t *testing.T) {
+	h, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	w, err := h.Watch(WithWatchPrefix("user:"))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer w.Close()
+
+	h.Put("user:1", 1)
+	h.Put("order:1", 2)
+	h.Delete("user:1")
+
+	if ev := <-w.C; ev.Kind != EventPut || ev.Key != "user:1" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+	if ev := <-w.C; ev.Kind != EventDelete || ev.Key != "user:1" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+	if len(w.C) != 0 {
+		t.Errorf("Expected other keys to be filtered out, %d events left", len(w.C))
+	}
+}
+
+// Test that a full non-blocking Watcher drops and counts events
+func TestWatch_DropsWhenFull(t *testing.T) {
+	h, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	w, _ := h.Watch(WithWatchBuffer(2))
+
+	for i := range 5 {
+		h.Put("a", i)
+	}
+
+	if len(w.C) != 2 || w.Dropped() != 3 {
+		t.Errorf("Expected 2 buffered and 3 dropped, got %d and %d", len(w.C), w.Dropped())
+	}
+
+	w.Close()
+	w.Close()
+	h.Put("b", 1)
+
+	n := 0
+	for range w.C {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Expected the 2 buffered events after Close, got %d", n)
+	}
+
+	if _, err := h.Watch(WithWatchBuffer(-1)); err == nil {
+		t.Error("Expected error for a negative buffer, got nil")
+	}
+}
+
+// Test that a blocking Watcher loses no events and Close releases the writer
+func TestWatch_Blocking(t *testing.T) {
+	h, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	w, _ := h.Watch(WithWatchBuffer(0), WithWatchBlocking())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			h.Put("a", i)
+		}
+	}()
+
+	for i := range 100 {
+		if ev := <-w.C; ev.New != i {
+			t.Fatalf("Expected value %d, got %d", i, ev.New)
+		}
+	}
+	<-done
+
+	if w.Dropped() != 0 {
+		t.Errorf("Expected no drops, got %d", w.Dropped())
+	}
+
+	// Close releases a writer blocked on a consumer that stopped reading
+	released := make(chan struct{})
+	go func() {
+		h.Put("b", 1)
+		close(released)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	w.Close()
+	<-released
+}
+
+// Test that loading a snapshot sends deletes and puts to watchers
+func TestWatch_ReadFrom(t *testing.T) {
+	h, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	h.Put("old", 1)
+
+	src, err := NewHashMapOf[string, int](8)
+	if err != nil {
+		t.Fatalf("Failed to create HashMap: %v", err)
+	}
+
+	src.Put("new", 2)
+
+	var buf bytes.Buffer
+	src.WriteTo(&buf)
+
+	w, _ := h.Watch()
+	defer w.Close()
+
+	if _, err := h.ReadFrom(&buf); err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+
+	if ev := <-w.C; ev.Kind != EventDelete || ev.Key != "old" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+	if ev := <-w.C; ev.Kind != EventPut || ev.Key != "new" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+
+	// Subscribers survive the load
+	h.Put("after", 3)
+	if ev := <-w.C; ev.Key != "after" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+}
+
+// Test that a ConcurrentHashMap Watcher sees events from every shard
+func TestConcurrentHashMap_Watch(t *testing.T) {
+	c, err := NewConcurrentHashMap[int, int](4, 64)
+	if err != nil {
+		t.Fatalf("Failed to create ConcurrentHashMap: %v", err)
+	}
+
+	w, _ := c.Watch(WithWatchPrefix("1"), WithWatchBuffer(100))
+	defer w.Close()
+
+	for i := range 20 {
+		c.Put(i, i)
+	}
+
+	// 1 and 10 through 19
+	if len(w.C) != 11 {
+		t.Errorf("Expected 11 events across shards, got %d", len(w.C))
+	}
+}